@@ -2,52 +2,250 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"k8s-gpu-monitoring/internal/alerting"
+	"k8s-gpu-monitoring/internal/auth"
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/exporter"
+	"k8s-gpu-monitoring/internal/federation"
 	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/kube"
+	"k8s-gpu-monitoring/internal/logging"
 	"k8s-gpu-monitoring/internal/middleware"
 	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/redact"
+	"k8s-gpu-monitoring/internal/source"
+	"k8s-gpu-monitoring/internal/stream"
+	"k8s-gpu-monitoring/internal/timeutil"
+	"k8s-gpu-monitoring/internal/tracing"
 )
 
 // main starts the GPU monitoring API server with graceful shutdown support.
 func main() {
+	// Configure structured logging before anything else logs
+	logger, err := logging.New(os.Stderr, getEnv("LOG_LEVEL", "info"), getEnv("LOG_FORMAT", "json"))
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	slog.SetDefault(logger)
+
 	// Load configuration from environment variables
+	metricsSource := getEnv("METRICS_SOURCE", "prometheus")
 	prometheusURL := getEnv("PROMETHEUS_URL", "http://localhost:9090")
+	clustersFile := getEnv("CLUSTERS_FILE", "")
+	prometheusTransport := prometheus.TransportConfig{
+		BasicAuthUsername:     getEnv("PROMETHEUS_BASIC_AUTH_USERNAME", ""),
+		BasicAuthPasswordFile: getEnv("PROMETHEUS_BASIC_AUTH_PASSWORD_FILE", ""),
+		BearerTokenFile:       getEnv("PROMETHEUS_BEARER_TOKEN_FILE", ""),
+		CAFile:                getEnv("PROMETHEUS_CA_FILE", ""),
+		CertFile:              getEnv("PROMETHEUS_CERT_FILE", ""),
+		KeyFile:               getEnv("PROMETHEUS_KEY_FILE", ""),
+		InsecureSkipVerify:    getEnvBool("PROMETHEUS_INSECURE_SKIP_VERIFY", false),
+		ProxyURL:              getEnv("PROMETHEUS_PROXY_URL", ""),
+		TenantID:              getEnv("PROMETHEUS_TENANT_ID", ""),
+		Headers:               getEnvMap("PROMETHEUS_HEADERS"),
+	}
+	prometheusRetry := prometheus.RetryOptions{
+		MaxAttempts: getEnvInt("PROMETHEUS_RETRY_ATTEMPTS", 3),
+		BaseDelay:   getEnvDuration("PROMETHEUS_RETRY_BASE_DELAY", 100*time.Millisecond),
+		MaxDelay:    getEnvDuration("PROMETHEUS_RETRY_MAX_DELAY", 2*time.Second),
+	}
+	prometheusBreaker := prometheus.BreakerOptions{
+		Threshold: getEnvInt("PROMETHEUS_BREAKER_THRESHOLD", 5),
+		Cooldown:  getEnvDuration("PROMETHEUS_BREAKER_COOLDOWN", 30*time.Second),
+	}
+	staleThreshold := getEnvDuration("STALE_THRESHOLD", 2*time.Minute)
+	metricSchema := getEnv("METRIC_SCHEMA", "custom")
+	metricSchemaFile := getEnv("METRIC_SCHEMA_FILE", "")
+	fixtureFile := getEnv("FIXTURE_FILE", "")
+	podAttribution := getEnvBool("POD_ATTRIBUTION", false)
+	alertRulesFile := getEnv("ALERT_RULES_FILE", "")
+	alertInterval := getEnvDuration("ALERT_INTERVAL", 30*time.Second)
+	authTokenFile := getEnv("AUTH_TOKEN_FILE", "")
+	oidcJWKSURL := getEnv("OIDC_JWKS_URL", "")
+	oidcIssuer := getEnv("OIDC_ISSUER", "")
+	oidcAudience := getEnv("OIDC_AUDIENCE", "")
+	oidcGroupsClaim := getEnv("OIDC_GROUPS_CLAIM", "groups")
+	redactionPolicyFile := getEnv("REDACTION_POLICY_FILE", "")
+	corsAllowedOrigins := getEnvList("CORS_ALLOWED_ORIGINS")
+	corsAllowedMethods := getEnvList("CORS_ALLOWED_METHODS")
+	corsAllowedHeaders := getEnvList("CORS_ALLOWED_HEADERS")
+	corsAllowCredentials := getEnvBool("CORS_ALLOW_CREDENTIALS", false)
+	otlpEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	serviceName := getEnv("OTEL_SERVICE_NAME", "gpu-monitoring-backend")
+	traceSampleRatio := getEnvFloat("TRACE_SAMPLE_RATIO", 1)
+	timeZone := getEnv("TIME_ZONE", "Asia/Tokyo")
+	timestampFormat := getEnv("TIMESTAMP_FORMAT", "rfc3339")
 	port := getEnv("PORT", "8080")
+	streamInterval := getEnvDuration("STREAM_INTERVAL", 5*time.Second)
+	cacheTTL := getEnvDuration("CACHE_TTL", 5*time.Second)
+	cacheMaxStale := getEnvDuration("CACHE_MAX_STALE", 5*time.Minute)
+
+	slog.Info("Starting GPU Monitoring API Server...",
+		"metrics_source", metricsSource,
+		"prometheus_url", prometheusURL,
+		"clusters_file", clustersFile,
+		"prometheus_auth", prometheusTransport.BasicAuthUsername != "" || prometheusTransport.BearerTokenFile != "",
+		"prometheus_mtls", prometheusTransport.CertFile != "",
+		"prometheus_insecure_skip_verify", prometheusTransport.InsecureSkipVerify,
+		"prometheus_retry_attempts", prometheusRetry.MaxAttempts,
+		"prometheus_breaker_threshold", prometheusBreaker.Threshold,
+		"prometheus_breaker_cooldown", prometheusBreaker.Cooldown,
+		"stale_threshold", staleThreshold,
+		"metric_schema", metricSchema,
+		"metric_schema_file", metricSchemaFile,
+		"pod_attribution", podAttribution,
+		"port", port,
+		"stream_interval", streamInterval,
+		"cache_ttl", cacheTTL,
+		"cache_max_stale", cacheMaxStale,
+		"alert_rules_file", alertRulesFile,
+		"alert_interval", alertInterval,
+		"cors_allowed_origins", corsAllowedOrigins,
+		"cors_allow_credentials", corsAllowCredentials,
+		"otlp_endpoint", otlpEndpoint,
+		"trace_sample_ratio", traceSampleRatio,
+		"time_zone", timeZone,
+		"timestamp_format", timestampFormat,
+	)
+
+	// Timestamps are produced in the default zone and converted per request
+	timeFormat, err := newTimeFormat(timeZone, timestampFormat)
+	if err != nil {
+		fatal("Invalid time format configuration", err)
+	}
+	timeutil.SetDefault(timeFormat)
+
+	// Export traces when a collector is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    otlpEndpoint,
+		ServiceName: serviceName,
+		SampleRatio: traceSampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
 
-	log.Printf("Starting GPU Monitoring API Server...")
-	log.Printf("Prometheus URL: %s", prometheusURL)
-	log.Printf("Server Port: %s", port)
+	// Only listed origins may call the API from a browser
+	cors, err := middleware.NewCORS(middleware.CORSOptions{
+		AllowedOrigins:   corsAllowedOrigins,
+		AllowedMethods:   corsAllowedMethods,
+		AllowedHeaders:   corsAllowedHeaders,
+		AllowCredentials: corsAllowCredentials,
+	})
+	if err != nil {
+		fatal("Invalid CORS configuration", err)
+	}
+
+	// Initialize authentication
+	verifiers, err := newVerifiers(authTokenFile, auth.JWTOptions{
+		JWKSURL:     oidcJWKSURL,
+		Issuer:      oidcIssuer,
+		Audience:    oidcAudience,
+		GroupsClaim: oidcGroupsClaim,
+	})
+	if err != nil {
+		fatal("Failed to initialize authentication", err)
+	}
+	slog.Info("Authentication configured", "enabled", len(verifiers) > 0)
 
-	// Initialize Prometheus client
-	promClient := prometheus.NewClient(prometheusURL)
+	// Load the redaction policy; without one processes are returned as is
+	var redaction *redact.Policy
+	if redactionPolicyFile != "" {
+		redaction, err = redact.LoadPolicy(redactionPolicyFile)
+		if err != nil {
+			fatal("Failed to load redaction policy", err)
+		}
+		slog.Info("Redaction policy loaded", "file", redactionPolicyFile)
+	}
+
+	// Initialize the metrics source
+	queryStats := exporter.NewQueryStats()
+	upstream, err := newMetricsSource(metricsSource, prometheusURL, clustersFile, prometheusTransport, metricSchema, metricSchemaFile, fixtureFile, podAttribution,
+		prometheus.WithObserver(queryStats),
+		prometheus.WithRetry(prometheusRetry),
+		prometheus.WithCircuitBreaker(prometheusBreaker),
+		prometheus.WithStaleThreshold(staleThreshold),
+	)
+	if err != nil {
+		fatal("Failed to initialize metrics source", err)
+	}
+
+	// Share snapshots between requests to keep upstream load independent of traffic
+	snapshots := cache.New(upstream, cache.Options{
+		TTL:      cacheTTL,
+		MaxStale: cacheMaxStale,
+	})
 
 	// Initialize handlers
-	gpuHandler := handlers.NewGPUHandler(promClient)
+	gpuHandler := handlers.NewGPUHandler(snapshots, handlers.WithRedaction(redaction))
+
+	// Start the shared poller for streaming clients
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	defer stopHub()
+	hub := stream.NewHub(snapshots.GetGPUMetrics, streamInterval)
+	go hub.Run(hubCtx)
+	streamHandler := handlers.NewStreamHandler(hub)
+
+	// Evaluate alert rules in the background when configured
+	alertEngine, err := newAlertEngine(alertRulesFile)
+	if err != nil {
+		fatal("Failed to initialize alerting", err)
+	}
+	if alertRulesFile != "" {
+		go alertEngine.Run(hubCtx, snapshots, alertInterval)
+	}
+	alertHandler := handlers.NewAlertHandler(alertEngine)
+	exporterHandler := handlers.NewExporterHandler(exporter.New(snapshots, snapshots, queryStats, exporter.WithRedaction(redaction)))
 
 	// Setup HTTP server and routes
 	mux := http.NewServeMux()
 
-	// Register API routes
+	// Register API routes; only the v1 API takes a time zone, so a bad one cannot break probes or static files
+	api := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, middleware.Timezone(handler))
+	}
 	mux.HandleFunc("GET /api/healthz", gpuHandler.HealthCheck)
-	mux.HandleFunc("GET /api/v1/gpu/metrics", gpuHandler.GetGPUMetrics)
-	mux.HandleFunc("GET /api/v1/gpu/processes", gpuHandler.GetGPUProcesses)
+	api("GET /api/v1/gpu/metrics", gpuHandler.GetGPUMetrics)
+	api("GET /api/v1/gpu/metrics/history", gpuHandler.GetGPUMetricsHistory)
+	api("GET /api/v1/gpu/metrics/stream", streamHandler.StreamGPUMetrics)
+	api("GET /api/v1/gpu/nodes", gpuHandler.GetGPUNodes)
+	api("GET /api/v1/gpu/utilization", gpuHandler.GetGPUUtilization)
+	api("GET /api/v1/gpu/idle", gpuHandler.GetIdleGPUs)
+	api("GET /api/v1/gpu/processes", gpuHandler.GetGPUProcesses)
+	api("GET /api/v1/gpu/usage/by-user", gpuHandler.GetGPUUsageByUser)
+	api("GET /api/v1/gpu/usage/by-namespace", gpuHandler.GetGPUUsageByNamespace)
+	api("GET /api/v1/alerts", alertHandler.GetAlerts)
+
+	// Expose derived data for Prometheus to scrape
+	mux.HandleFunc("GET /metrics", exporterHandler.GetMetrics)
 
 	// Serve static files for frontend
 	mux.Handle("GET /", http.FileServer(http.Dir("./static/")))
 
 	// Apply middleware chain
-	handler := middleware.Chain(
-		mux,
+	middlewares := []func(http.Handler) http.Handler{
+		middleware.RequestID,
+		middleware.Tracing(mux),
 		middleware.Logger,
-		middleware.CORS,
+		cors,
 		middleware.Recovery,
-	)
+	}
+	if len(verifiers) > 0 {
+		middlewares = append(middlewares, auth.Middleware(verifiers...))
+	}
+	handler := middleware.Chain(mux, middlewares...)
 
 	// Configure HTTP server with timeouts
 	server := &http.Server{
@@ -58,11 +256,14 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Close open streams so shutdown does not wait on them
+	server.RegisterOnShutdown(stopHub)
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %s", port)
+		slog.Info("Server starting", "port", port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			fatal("Server failed to start", err)
 		}
 	}()
 
@@ -71,17 +272,134 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Server shutting down...")
+	slog.Info("Server shutting down...")
 
 	// Shutdown server with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Error flushing traces", "error", err)
+	}
+
+	slog.Info("Server exited")
+}
+
+// newMetricsSource creates the metrics source selected by kind.
+// A clusters file federates the Prometheus servers it lists in place of prometheusURL,
+// each connecting with its own settings on top of transport. Every Prometheus client is created with clientOpts.
+func newMetricsSource(kind, prometheusURL, clustersFile string, transport prometheus.TransportConfig, metricSchema, metricSchemaFile, fixtureFile string, podAttribution bool, clientOpts ...prometheus.Option) (source.MetricsSource, error) {
+	switch kind {
+	case "prometheus":
+		schema, err := loadSchema(metricSchema, metricSchemaFile)
+		if err != nil {
+			return nil, err
+		}
+		clientOpts = append(clientOpts, prometheus.WithSchema(schema))
+		if clustersFile == "" {
+			return newPrometheusSource(prometheusURL, transport, podAttribution, clientOpts)
+		}
+
+		cfg, err := federation.LoadConfig(clustersFile)
+		if err != nil {
+			return nil, err
+		}
+		clusters := make([]federation.Cluster, 0, len(cfg.Clusters))
+		for _, c := range cfg.Clusters {
+			src, err := newPrometheusSource(c.URL, c.TransportConfig.WithDefaults(transport), podAttribution, clientOpts)
+			if err != nil {
+				return nil, fmt.Errorf("cluster %s: %w", c.Name, err)
+			}
+			clusters = append(clusters, federation.Cluster{Name: c.Name, Source: src})
+		}
+		slog.Info("Federating clusters", "clusters", len(clusters), "file", clustersFile)
+		return federation.New(clusters), nil
+	case "fixture":
+		if fixtureFile == "" {
+			return source.NewFixture(nil, nil), nil
+		}
+		return source.LoadFixture(fixtureFile)
+	default:
+		return nil, fmt.Errorf("unknown metrics source %q", kind)
 	}
+}
 
-	log.Println("Server exited")
+// newAlertEngine creates the alerting engine from the rules file; without one no rules are evaluated.
+func newAlertEngine(path string) (*alerting.Engine, error) {
+	if path == "" {
+		return alerting.NewEngine(nil), nil
+	}
+	cfg, err := alerting.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return alerting.NewEngine(cfg.Rules, alerting.NewNotifiers(cfg.Notifiers)...), nil
+}
+
+// newVerifiers creates the bearer token verifiers that are configured; none means authentication is disabled.
+func newVerifiers(tokenFile string, jwt auth.JWTOptions) ([]auth.Verifier, error) {
+	var verifiers []auth.Verifier
+	if tokenFile != "" {
+		tokens, err := auth.LoadTokenFile(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, tokens)
+	}
+	if jwt.JWKSURL != "" {
+		verifiers = append(verifiers, auth.NewJWTVerifier(jwt))
+	}
+	return verifiers, nil
+}
+
+// newPrometheusSource creates a source reading from the Prometheus server at url.
+// Each source gets its own client, so clusters have independent circuit breakers.
+func newPrometheusSource(url string, transport prometheus.TransportConfig, podAttribution bool, opts []prometheus.Option) (source.MetricsSource, error) {
+	rt, err := prometheus.NewTransport(transport)
+	if err != nil {
+		return nil, fmt.Errorf("configuring Prometheus connection: %w", err)
+	}
+
+	client := prometheus.NewClient(url, append(slices.Clip(opts), prometheus.WithTransport(rt))...)
+	if podAttribution {
+		// kube-state-metrics is expected in the same Prometheus
+		return kube.NewEnricher(client, client), nil
+	}
+	return client, nil
+}
+
+// loadSchema returns the schema from the mapping file when given, otherwise the named built-in schema.
+func loadSchema(name, path string) (prometheus.Schema, error) {
+	if path != "" {
+		return prometheus.LoadSchema(path)
+	}
+	return prometheus.LookupSchema(name)
+}
+
+// newTimeFormat returns the default response time format for the IANA zone name and layout,
+// either "rfc3339" or "legacy" for "YYYY/MM/DD HH:MM:SS".
+func newTimeFormat(zone, layout string) (timeutil.Format, error) {
+	loc, err := timeutil.LoadLocation(zone)
+	if err != nil {
+		return timeutil.Format{}, err
+	}
+	switch layout {
+	case "rfc3339":
+		return timeutil.Format{Location: loc}, nil
+	case "legacy":
+		return timeutil.Format{Location: loc, Legacy: true}, nil
+	default:
+		return timeutil.Format{}, fmt.Errorf("unknown timestamp format %q", layout)
+	}
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
 // getEnv retrieves environment variable value with fallback to default.
@@ -91,3 +409,84 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList retrieves a comma-separated environment variable, dropping empty items.
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getEnvMap retrieves a comma-separated list of "key=value" pairs, skipping malformed entries.
+func getEnvMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range getEnvList(key) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			slog.Warn("Invalid environment variable entry, ignoring", "key", key, "entry", pair)
+			continue
+		}
+		values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+// getEnvInt retrieves an integer environment variable with fallback to default.
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return i
+}
+
+// getEnvFloat retrieves a float environment variable with fallback to default.
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+// getEnvDuration retrieves a duration environment variable with fallback to default.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvBool retrieves a boolean environment variable with fallback to default.
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return b
+}
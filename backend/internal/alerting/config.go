@@ -0,0 +1,182 @@
+// Package alerting evaluates alert rules against GPU snapshots and delivers notifications.
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Metric names a rule condition can test. Most are GPUMetrics JSON field names.
+const (
+	MetricGPUUtilization    = "gpu_utilization"
+	MetricGPUTemperature    = "temperature"
+	MetricGPUMemoryUsed     = "gpu_memory_used"
+	MetricGPUMemoryFree     = "memory_free"
+	MetricGPUMemoryPercent  = "gpu_memory_used_percent"
+	MetricCPUUtilization    = "cpu_utilization"
+	MetricMemoryUtilization = "memory_utilization"
+	// MetricProcessGPUMemory is the GPU memory held by processes on the GPU.
+	MetricProcessGPUMemory = "process_gpu_memory"
+	// MetricProcessCount is the number of processes on the GPU.
+	MetricProcessCount = "process_count"
+)
+
+// metrics lists the metric names a condition may use.
+var metrics = []string{
+	MetricGPUUtilization,
+	MetricGPUTemperature,
+	MetricGPUMemoryUsed,
+	MetricGPUMemoryFree,
+	MetricGPUMemoryPercent,
+	MetricCPUUtilization,
+	MetricMemoryUtilization,
+	MetricProcessGPUMemory,
+	MetricProcessCount,
+}
+
+// operators lists the comparison operators a condition may use.
+var operators = []string{">", ">=", "<", "<=", "==", "!="}
+
+// Config holds the alert rules and where notifications are delivered.
+type Config struct {
+	Rules     []Rule           `json:"rules" yaml:"rules"`
+	Notifiers []NotifierConfig `json:"notifiers" yaml:"notifiers"`
+}
+
+// Rule fires for a GPU when all of its conditions hold continuously for For.
+type Rule struct {
+	Name       string      `json:"name" yaml:"name"`
+	Severity   string      `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Summary    string      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	For        Duration    `json:"for,omitempty" yaml:"for,omitempty"`
+	Conditions []Condition `json:"conditions" yaml:"conditions"`
+}
+
+// Condition compares a single GPU metric with a threshold.
+type Condition struct {
+	Metric    string  `json:"metric" yaml:"metric"`
+	Op        string  `json:"op" yaml:"op"`
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+}
+
+// NotifierConfig configures a single notification destination.
+type NotifierConfig struct {
+	// Type is "webhook" for the generic JSON payload or "slack" for Slack-compatible incoming webhooks.
+	Type string `json:"type" yaml:"type"`
+	URL  string `json:"url" yaml:"url"`
+}
+
+// Duration is a time.Duration read from strings such as "5m".
+type Duration time.Duration
+
+// UnmarshalText parses a Go duration string.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// LoadConfig reads alerting configuration from a YAML or JSON file, chosen by extension.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading alert rules: %w", err)
+	}
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("parsing alert rules %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid alert rules %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate reports every problem in the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("rules[%d]: name is not set", i))
+		} else if names[rule.Name] {
+			errs = append(errs, fmt.Errorf("rules[%d]: duplicate rule name %q", i, rule.Name))
+		}
+		names[rule.Name] = true
+
+		if rule.For < 0 {
+			errs = append(errs, fmt.Errorf("rules[%d]: for must not be negative", i))
+		}
+		if len(rule.Conditions) == 0 {
+			errs = append(errs, fmt.Errorf("rules[%d]: at least one condition is required", i))
+		}
+		for j, cond := range rule.Conditions {
+			if !slices.Contains(metrics, cond.Metric) {
+				errs = append(errs, fmt.Errorf("rules[%d].conditions[%d]: unknown metric %q", i, j, cond.Metric))
+			}
+			if !slices.Contains(operators, cond.Op) {
+				errs = append(errs, fmt.Errorf("rules[%d].conditions[%d]: unknown operator %q", i, j, cond.Op))
+			}
+		}
+	}
+
+	for i, n := range c.Notifiers {
+		if n.Type != "webhook" && n.Type != "slack" {
+			errs = append(errs, fmt.Errorf("notifiers[%d]: unknown type %q", i, n.Type))
+		}
+		if n.URL == "" {
+			errs = append(errs, fmt.Errorf("notifiers[%d]: url is not set", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// matches reports whether value satisfies the condition.
+func (c Condition) matches(value float64) bool {
+	switch c.Op {
+	case ">":
+		return value > c.Threshold
+	case ">=":
+		return value >= c.Threshold
+	case "<":
+		return value < c.Threshold
+	case "<=":
+		return value <= c.Threshold
+	case "==":
+		return value == c.Threshold
+	case "!=":
+		return value != c.Threshold
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// Engine evaluates alert rules against GPU snapshots and tracks pending and firing alerts per GPU.
+type Engine struct {
+	rules     []Rule
+	notifiers []Notifier
+
+	mu     sync.Mutex
+	active map[alertKey]*alertState
+}
+
+// alertKey identifies an alert instance: one rule on one GPU.
+type alertKey struct {
+	rule    string
+	cluster string
+	node    string
+	gpu     int
+}
+
+// alertState tracks an alert from the first evaluation its conditions held.
+type alertState struct {
+	rule        *Rule
+	gpuName     string
+	values      map[string]float64
+	activeSince time.Time
+	firedAt     time.Time
+}
+
+// NewEngine creates a new engine evaluating rules and sending state changes to notifiers.
+func NewEngine(rules []Rule, notifiers ...Notifier) *Engine {
+	return &Engine{
+		rules:     rules,
+		notifiers: notifiers,
+		active:    make(map[alertKey]*alertState),
+	}
+}
+
+// Run evaluates the rules against src every interval until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context, src source.MetricsSource, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.poll(ctx, src)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches a snapshot, evaluates it and delivers the resulting notifications.
+// A failed fetch, or a stale snapshot served in its place, leaves alert state untouched
+// rather than resolving everything.
+func (e *Engine) poll(ctx context.Context, src source.MetricsSource) {
+	pollCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	pollCtx, rep := report.NewContext(pollCtx)
+
+	metrics, err := src.GetGPUMetrics(pollCtx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting GPU metrics for alerting", "error", err)
+		return
+	}
+	processes, err := src.GetGPUProcesses(pollCtx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting GPU processes for alerting", "error", err)
+		return
+	}
+	if stale, fetchedAt := rep.Stale(); stale {
+		slog.WarnContext(ctx, "Skipping alert evaluation of a stale snapshot", "fetched_at", fetchedAt)
+		return
+	}
+
+	if changes := e.Evaluate(metrics, processes, time.Now()); len(changes) > 0 {
+		e.notify(pollCtx, changes)
+	}
+}
+
+// Evaluate updates alert state from a snapshot taken at now and returns the alerts that
+// started firing or were resolved. Alerts of GPUs missing from the snapshot are resolved, while
+// alerts whose conditions cannot be tested because a metric is missing, e.g. after a failed
+// query, or because the GPU is flagged stale keep their state until they can.
+func (e *Engine) Evaluate(metrics []models.GPUMetrics, processes []models.GPUProcess, now time.Time) []models.Alert {
+	samples := sampleGPUs(metrics, processes)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var changes []models.Alert
+	seen := make(map[alertKey]bool)
+
+	for i := range e.rules {
+		rule := &e.rules[i]
+		for _, m := range metrics {
+			key := alertKey{rule: rule.Name, cluster: m.Cluster, node: m.NodeName, gpu: m.GPUIndex}
+			values, ok, known := rule.evaluate(samples[gpuKey(m.Cluster, m.NodeName, m.GPUIndex)])
+			if !known || m.Stale {
+				// Neither start nor resolve an alert on missing data
+				seen[key] = true
+				continue
+			}
+			if !ok {
+				continue
+			}
+			seen[key] = true
+
+			state, exists := e.active[key]
+			if !exists {
+				state = &alertState{rule: rule, activeSince: now}
+				e.active[key] = state
+			}
+			state.gpuName = m.GPUName
+			state.values = values
+
+			if state.firedAt.IsZero() && now.Sub(state.activeSince) >= time.Duration(rule.For) {
+				state.firedAt = now
+				changes = append(changes, state.alert(key, models.AlertStateFiring, time.Time{}))
+			}
+		}
+	}
+
+	for key, state := range e.active {
+		if seen[key] {
+			continue
+		}
+		delete(e.active, key)
+		if !state.firedAt.IsZero() {
+			changes = append(changes, state.alert(key, models.AlertStateResolved, now))
+		}
+	}
+
+	sortAlerts(changes)
+	return changes
+}
+
+// Alerts returns the pending and firing alerts.
+func (e *Engine) Alerts() []models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]models.Alert, 0, len(e.active))
+	for key, state := range e.active {
+		alertState := models.AlertStatePending
+		if !state.firedAt.IsZero() {
+			alertState = models.AlertStateFiring
+		}
+		alerts = append(alerts, state.alert(key, alertState, time.Time{}))
+	}
+
+	sortAlerts(alerts)
+	return alerts
+}
+
+// notify delivers alerts to every notifier, logging failures.
+func (e *Engine) notify(ctx context.Context, alerts []models.Alert) {
+	for _, n := range e.notifiers {
+		if err := n.Notify(ctx, alerts); err != nil {
+			slog.ErrorContext(ctx, "Error sending alert notification", "error", err)
+		}
+	}
+}
+
+// alert converts the tracked state into its API representation.
+func (s *alertState) alert(key alertKey, state string, resolvedAt time.Time) models.Alert {
+	a := models.Alert{
+		Rule:        key.rule,
+		Severity:    s.rule.Severity,
+		State:       state,
+		Cluster:     key.cluster,
+		NodeName:    key.node,
+		GPUIndex:    key.gpu,
+		GPUName:     s.gpuName,
+		Summary:     s.rule.Summary,
+		Values:      s.values,
+		ActiveSince: timeutil.FormatRFC3339(s.activeSince),
+	}
+	if !s.firedAt.IsZero() {
+		a.FiredAt = timeutil.FormatRFC3339(s.firedAt)
+	}
+	if !resolvedAt.IsZero() {
+		a.ResolvedAt = timeutil.FormatRFC3339(resolvedAt)
+	}
+	return a
+}
+
+// evaluate reports whether all conditions hold for sample, returning the values they were tested against.
+// known is false when no condition fails but one tests a metric missing from the sample.
+func (r *Rule) evaluate(sample map[string]float64) (values map[string]float64, ok, known bool) {
+	values = make(map[string]float64, len(r.Conditions))
+	known = true
+	for _, cond := range r.Conditions {
+		value, present := sample[cond.Metric]
+		if !present {
+			known = false
+			continue
+		}
+		if !cond.matches(value) {
+			return nil, false, true
+		}
+		values[cond.Metric] = value
+	}
+	if !known {
+		return nil, false, false
+	}
+	return values, true, true
+}
+
+// sampleGPUs collects the metric values conditions can test, keyed by "node:gpu".
+// Values missing from the metrics are left out, so conditions on them cannot be tested,
+// as are the process totals of GPUs with a stale process.
+func sampleGPUs(metrics []models.GPUMetrics, processes []models.GPUProcess) map[string]map[string]float64 {
+	samples := make(map[string]map[string]float64, len(metrics))
+	for _, m := range metrics {
+		sample := map[string]float64{
+			MetricProcessGPUMemory: 0,
+			MetricProcessCount:     0,
+		}
+		for metric, value := range map[string]*float64{
+			MetricGPUUtilization:    m.GPUUtilization,
+			MetricGPUTemperature:    m.GPUTemperature,
+			MetricCPUUtilization:    m.CPUUtilization,
+			MetricMemoryUtilization: m.MemoryUtilization,
+		} {
+			if value != nil {
+				sample[metric] = *value
+			}
+		}
+		for metric, value := range map[string]*int{
+			MetricGPUMemoryUsed: m.GPUMemoryUsed,
+			MetricGPUMemoryFree: m.GPUMemoryFree,
+		} {
+			if value != nil {
+				sample[metric] = float64(*value)
+			}
+		}
+		if m.GPUMemoryUsed != nil && m.GPUMemoryTotal != nil && *m.GPUMemoryTotal > 0 {
+			sample[MetricGPUMemoryPercent] = float64(*m.GPUMemoryUsed) / float64(*m.GPUMemoryTotal) * 100
+		}
+		samples[gpuKey(m.Cluster, m.NodeName, m.GPUIndex)] = sample
+	}
+
+	staleProcesses := make(map[string]bool)
+	for _, p := range processes {
+		key := gpuKey(p.Cluster, p.NodeName, p.GPUIndex)
+		sample, ok := samples[key]
+		if !ok {
+			continue
+		}
+		if p.Stale {
+			staleProcesses[key] = true
+		}
+		sample[MetricProcessGPUMemory] += float64(p.GPUMemory)
+		sample[MetricProcessCount]++
+	}
+	for key := range staleProcesses {
+		delete(samples[key], MetricProcessGPUMemory)
+		delete(samples[key], MetricProcessCount)
+	}
+
+	return samples
+}
+
+// gpuKey returns the "cluster:node:gpu" key identifying a GPU.
+func gpuKey(cluster, node string, gpu int) string {
+	return fmt.Sprintf("%s:%s:%d", cluster, node, gpu)
+}
+
+// sortAlerts orders alerts by rule, cluster, node and GPU index.
+func sortAlerts(alerts []models.Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+		if alerts[i].Cluster != alerts[j].Cluster {
+			return alerts[i].Cluster < alerts[j].Cluster
+		}
+		if alerts[i].NodeName != alerts[j].NodeName {
+			return alerts[i].NodeName < alerts[j].NodeName
+		}
+		return alerts[i].GPUIndex < alerts[j].GPUIndex
+	})
+}
@@ -0,0 +1,134 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// Notifier delivers alerts that started firing or were resolved.
+type Notifier interface {
+	Notify(ctx context.Context, alerts []models.Alert) error
+}
+
+// WebhookPayload is the JSON body posted by WebhookNotifier.
+type WebhookPayload struct {
+	Alerts []models.Alert `json:"alerts"`
+}
+
+// WebhookNotifier posts alerts as JSON to a generic webhook.
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// SlackNotifier posts alerts as text to a Slack-compatible incoming webhook.
+type SlackNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a new notifier posting to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewSlackNotifier creates a new notifier posting to a Slack incoming webhook url.
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewNotifiers creates the notifiers described by cfgs.
+func NewNotifiers(cfgs []NotifierConfig) []Notifier {
+	notifiers := make([]Notifier, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		switch cfg.Type {
+		case "webhook":
+			notifiers = append(notifiers, NewWebhookNotifier(cfg.URL))
+		case "slack":
+			notifiers = append(notifiers, NewSlackNotifier(cfg.URL))
+		}
+	}
+	return notifiers
+}
+
+// Notify posts the alerts as a WebhookPayload.
+func (n *WebhookNotifier) Notify(ctx context.Context, alerts []models.Alert) error {
+	return postJSON(ctx, n.httpClient, n.url, WebhookPayload{Alerts: alerts})
+}
+
+// Notify posts one message summarizing the alerts.
+func (n *SlackNotifier) Notify(ctx context.Context, alerts []models.Alert) error {
+	lines := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		lines = append(lines, slackLine(a))
+	}
+	return postJSON(ctx, n.httpClient, n.url, map[string]string{"text": strings.Join(lines, "\n")})
+}
+
+// slackLine formats a single alert, e.g. "[FIRING] HighTemperature on node1 GPU 0 (warning): temperature=85".
+// Alerts from a federated cluster name the node as "cluster/node".
+func slackLine(a models.Alert) string {
+	var b strings.Builder
+	node := a.NodeName
+	if a.Cluster != "" {
+		node = a.Cluster + "/" + a.NodeName
+	}
+	fmt.Fprintf(&b, "[%s] %s on %s GPU %d", strings.ToUpper(a.State), a.Rule, node, a.GPUIndex)
+	if a.Severity != "" {
+		fmt.Fprintf(&b, " (%s)", a.Severity)
+	}
+
+	values := make([]string, 0, len(a.Values))
+	for metric, value := range a.Values {
+		values = append(values, fmt.Sprintf("%s=%g", metric, value))
+	}
+	if len(values) > 0 {
+		sort.Strings(values)
+		b.WriteString(": " + strings.Join(values, ", "))
+	}
+	if a.Summary != "" {
+		b.WriteString(" - " + a.Summary)
+	}
+	return b.String()
+}
+
+// postJSON posts body as JSON to url and treats non-2xx responses as errors.
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification to %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package auth authenticates API requests with static bearer tokens or OIDC-issued JWTs.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"k8s-gpu-monitoring/internal/logging"
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// ErrInvalidToken is returned by verifiers that do not accept a token.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal identifies an authenticated caller.
+type Principal struct {
+	// Subject is the token name for static tokens or the "sub" claim for JWTs.
+	Subject string
+	// Method is "token" or "oidc".
+	Method string
+	// Groups are the groups or roles the caller belongs to.
+	Groups []string
+}
+
+// Verifier checks a bearer token and returns the caller it belongs to.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (Principal, error)
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the authenticated caller of a request, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Middleware rejects requests to protected paths without a bearer token accepted by one of verifiers.
+// The API under /api/ and the /metrics endpoint are protected, except the health check and CORS preflights.
+func Middleware(verifiers ...Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !protected(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				unauthorized(w, r, "")
+				return
+			}
+
+			for _, v := range verifiers {
+				principal, err := v.Verify(r.Context(), token)
+				if err == nil {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+					return
+				}
+				if !errors.Is(err, ErrInvalidToken) {
+					slog.WarnContext(r.Context(), "Error verifying bearer token", "error", err)
+				}
+			}
+
+			unauthorized(w, r, "invalid_token")
+		})
+	}
+}
+
+// protected reports whether r requires authentication.
+func protected(r *http.Request) bool {
+	if r.Method == http.MethodOptions || r.URL.Path == "/api/healthz" {
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/metrics"
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthorized writes a 401 response in the standard API format.
+func unauthorized(w http.ResponseWriter, r *http.Request, errorCode string) {
+	challenge := `Bearer realm="gpu-monitoring"`
+	if errorCode != "" {
+		challenge += `, error="` + errorCode + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success:   false,
+		Error:     "Unauthorized",
+		RequestID: logging.RequestID(r.Context()),
+	})
+}
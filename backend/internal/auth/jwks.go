@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksRefreshInterval is how long a fetched key set is used before refetching it.
+	jwksRefreshInterval = time.Hour
+	// jwksMinRefreshInterval limits refetches of a key set, whether triggered by expiry or by tokens
+	// signed with unknown keys, and whether the previous attempt succeeded or not.
+	jwksMinRefreshInterval = time.Minute
+	// jwksRetryInterval limits fetch attempts while no key set has been fetched yet.
+	jwksRetryInterval = 10 * time.Second
+)
+
+// keySet caches the signing keys published at a JWKS URL. The set is fetched without holding mu,
+// and concurrent callers share a single fetch.
+type keySet struct {
+	url        string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey // key: kid
+	fetchedAt   time.Time                   // last successful fetch
+	attemptedAt time.Time                   // last fetch attempt, successful or not
+	fetchErr    error                       // error of the last fetch attempt
+	fetching    chan struct{}               // closed when the running fetch completes; nil when none runs
+}
+
+// jwk is a single JSON Web Key; only the members needed for RSA and EC public keys are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// key returns the public key with the given kid. An empty kid matches the only key of the set.
+// An expired set is refreshed in the background while its keys keep being served, so a JWKS outage
+// only affects keys the set does not have yet; an unknown kid waits for a refetch, as the issuer
+// may have rotated its keys.
+func (ks *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	key, ok := ks.lookup(kid)
+	switch {
+	case ok && time.Since(ks.fetchedAt) >= jwksRefreshInterval && ks.canFetch():
+		ks.startFetch(ctx)
+	case !ok && (ks.fetching != nil || ks.canFetch()):
+		done := ks.startFetch(ctx)
+		ks.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		ks.mu.Lock()
+		key, ok = ks.lookup(kid)
+	}
+	fetched, fetchErr := ks.keys != nil, ks.fetchErr
+	ks.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+	if !fetched && fetchErr != nil {
+		return nil, fetchErr
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+// canFetch reports whether a new fetch may start, rate-limiting attempts. Callers must hold ks.mu.
+func (ks *keySet) canFetch() bool {
+	interval := jwksMinRefreshInterval
+	if ks.keys == nil {
+		interval = jwksRetryInterval
+	}
+	return ks.fetching == nil && time.Since(ks.attemptedAt) >= interval
+}
+
+// startFetch starts fetching the key set unless a fetch is already running, and returns a channel
+// closed when the running fetch completes. A failed fetch keeps the cached set. Callers must hold ks.mu.
+func (ks *keySet) startFetch(ctx context.Context) <-chan struct{} {
+	if ks.fetching != nil {
+		return ks.fetching
+	}
+	done := make(chan struct{})
+	ks.fetching = done
+	ks.attemptedAt = time.Now()
+
+	// The fetch is shared, so it must outlive the request that started it; the client timeout bounds it
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		keys, err := ks.fetch(ctx)
+
+		ks.mu.Lock()
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to fetch JWKS, keeping the cached keys", "url", ks.url, "error", err)
+		} else {
+			ks.keys = keys
+			ks.fetchedAt = time.Now()
+		}
+		ks.fetchErr = err
+		ks.fetching = nil
+		ks.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// lookup finds a key in the cached set. Callers must hold ks.mu.
+func (ks *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// fetch returns the keys currently published at the JWKS URL.
+func (ks *keySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := ks.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip keys we cannot use rather than rejecting the whole set
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA or EC public key.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures OIDC token validation.
+type JWTOptions struct {
+	// JWKSURL is where the issuer publishes its signing keys.
+	JWKSURL string
+	// Issuer, when set, must match the "iss" claim.
+	Issuer string
+	// Audience, when set, must be contained in the "aud" claim.
+	Audience string
+	// Leeway tolerates clock skew when checking "exp" and "nbf".
+	Leeway time.Duration
+	// GroupsClaim names the claim listing the caller's groups. Defaults to "groups".
+	GroupsClaim string
+}
+
+// JWTVerifier validates JWTs signed with keys from a JWKS endpoint.
+type JWTVerifier struct {
+	opts JWTOptions
+	keys *keySet
+}
+
+// claims are the registered claims checked by JWTVerifier.
+type claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience accepts the "aud" claim, or a groups claim, as either a string or an array of strings.
+type audience []string
+
+// UnmarshalJSON decodes a string or string array.
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// NewJWTVerifier creates a new verifier fetching keys from opts.JWKSURL.
+func NewJWTVerifier(opts JWTOptions) *JWTVerifier {
+	if opts.Leeway == 0 {
+		opts.Leeway = time.Minute
+	}
+	if opts.GroupsClaim == "" {
+		opts.GroupsClaim = "groups"
+	}
+	return &JWTVerifier{
+		opts: opts,
+		keys: &keySet{
+			url:        opts.JWKSURL,
+			httpClient: &http.Client{Timeout: 10 * time.Second},
+		},
+	}
+}
+
+// Verify checks the signature and claims of token.
+// Errors wrapping ErrInvalidToken mean the token was rejected; others mean the keys could not be fetched.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed JWT", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+
+	hash, ok := signatureHashes[header.Alg]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	if err := verifySignature(header.Alg, hash, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Principal{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(c); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return Principal{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	var groups audience
+	if data, ok := raw[v.opts.GroupsClaim]; ok {
+		// A malformed groups claim grants no groups rather than rejecting the token
+		json.Unmarshal(data, &groups)
+	}
+
+	return Principal{Subject: c.Subject, Method: "oidc", Groups: groups}, nil
+}
+
+// checkClaims validates expiry, not-before, issuer and audience.
+func (v *JWTVerifier) checkClaims(c claims) error {
+	now := time.Now()
+
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(v.opts.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if c.NotBefore != nil && now.Add(v.opts.Leeway).Before(unixTime(*c.NotBefore)) {
+		return fmt.Errorf("token not yet valid")
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if v.opts.Audience != "" && !slices.Contains(c.Audience, v.opts.Audience) {
+		return fmt.Errorf("token not issued for audience %q", v.opts.Audience)
+	}
+	return nil
+}
+
+// signatureHashes maps the supported JWS algorithms to their hash functions.
+var signatureHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ecdsaCurveBits is the curve size each ECDSA algorithm must be used with.
+var ecdsaCurveBits = map[string]int{
+	"ES256": 256,
+	"ES384": 384,
+	"ES512": 521,
+}
+
+// verifySignature checks a JWS signature over signingInput with key, which must suit alg.
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signingInput string, signature []byte) error {
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		case "PS":
+			return rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" || k.Curve.Params().BitSize != ecdsaCurveBits[alg] {
+			break
+		}
+		// JWS encodes ECDSA signatures as fixed-size r || s
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid ECDSA signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %s does not match signing key", alg)
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT into v.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a NumericDate claim to time.Time.
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
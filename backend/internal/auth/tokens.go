@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TokenFile accepts a fixed set of bearer tokens.
+type TokenFile struct {
+	tokens []staticToken
+}
+
+// staticToken is a token hash and the caller it authenticates.
+type staticToken struct {
+	name   string
+	groups []string
+	hash   [sha256.Size]byte
+}
+
+// LoadTokenFile reads bearer tokens from path, one per line as "token", "name:token"
+// or "name:group1,group2:token". Blank lines and lines starting with "#" are ignored.
+func LoadTokenFile(path string) (*TokenFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading token file: %w", err)
+	}
+	defer f.Close()
+
+	tf := &TokenFile{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var name, groups, token string
+		switch fields := strings.SplitN(text, ":", 3); len(fields) {
+		case 1:
+			name, token = fmt.Sprintf("token-%d", line), fields[0]
+		case 2:
+			name, token = fields[0], fields[1]
+		default:
+			name, groups, token = fields[0], fields[1], fields[2]
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return nil, fmt.Errorf("token file %s line %d: empty token", path, line)
+		}
+
+		tf.tokens = append(tf.tokens, staticToken{
+			name:   strings.TrimSpace(name),
+			groups: splitGroups(groups),
+			hash:   sha256.Sum256([]byte(token)),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading token file: %w", err)
+	}
+	if len(tf.tokens) == 0 {
+		return nil, fmt.Errorf("token file %s contains no tokens", path)
+	}
+
+	return tf, nil
+}
+
+// Verify accepts token if it is listed in the file. Comparison is constant-time.
+func (tf *TokenFile) Verify(ctx context.Context, token string) (Principal, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	var match *staticToken
+	for i := range tf.tokens {
+		if subtle.ConstantTimeCompare(hash[:], tf.tokens[i].hash[:]) == 1 {
+			match = &tf.tokens[i]
+		}
+	}
+	if match == nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	return Principal{Subject: match.name, Method: "token", Groups: match.groups}, nil
+}
+
+// splitGroups parses a comma-separated group list.
+func splitGroups(s string) []string {
+	var groups []string
+	for _, g := range strings.Split(s, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// Options configures snapshot caching behaviour.
+type Options struct {
+	// TTL is how long a snapshot is served without contacting the upstream.
+	TTL time.Duration
+	// MaxStale is how long after its fetch a snapshot may still be served when refreshing fails.
+	// Zero means a stale snapshot is served for as long as the upstream keeps failing.
+	MaxStale time.Duration
+	// FetchTimeout bounds a single upstream fetch shared by coalesced callers.
+	FetchTimeout time.Duration
+}
+
+// Cache serves GPU snapshots from memory, coalesces concurrent refreshes into a single
+// upstream fetch and falls back to the last known snapshot when the upstream fails.
+// The slices it returns are shared between callers and must not be modified.
+type Cache struct {
+	upstream  source.MetricsSource
+	opts      Options
+	stats     counters
+	metrics   entry[[]models.GPUMetrics]
+	processes entry[[]models.GPUProcess]
+}
+
+// Stats counts how cached reads were served.
+type Stats struct {
+	// Hits are reads served from a fresh snapshot.
+	Hits uint64
+	// Misses are reads that waited for an upstream fetch, including coalesced ones.
+	Misses uint64
+	// Stale are misses whose fetch failed and were served the last known snapshot.
+	Stale uint64
+}
+
+// counters accumulates Stats.
+type counters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	stale  atomic.Uint64
+}
+
+// entry holds one cached snapshot and the in-flight refresh for it, if any.
+type entry[T any] struct {
+	mu        sync.Mutex
+	value     T
+	report    *report.Report
+	fetchedAt time.Time
+	valid     bool
+	inflight  *call[T]
+}
+
+// call represents a single upstream fetch awaited by one or more callers.
+// report holds the annotations, such as partial-result warnings, the fetch produced.
+type call[T any] struct {
+	done   chan struct{}
+	value  T
+	report *report.Report
+	err    error
+}
+
+var (
+	_ source.MetricsSource = (*Cache)(nil)
+	_ source.Wrapper       = (*Cache)(nil)
+)
+
+// New creates a new snapshot cache in front of upstream.
+func New(upstream source.MetricsSource, opts Options) *Cache {
+	if opts.FetchTimeout <= 0 {
+		opts.FetchTimeout = 30 * time.Second
+	}
+	return &Cache{
+		upstream: upstream,
+		opts:     opts,
+	}
+}
+
+// GetGPUMetrics returns cached GPU metrics, refreshing them from the upstream when expired.
+func (c *Cache) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	return c.metrics.get(ctx, c.opts, &c.stats, c.upstream.GetGPUMetrics)
+}
+
+// GetGPUProcesses returns cached GPU processes, refreshing them from the upstream when expired.
+func (c *Cache) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	return c.processes.get(ctx, c.opts, &c.stats, c.upstream.GetGPUProcesses)
+}
+
+// Ping checks the upstream directly; health probes are never cached.
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.upstream.Ping(ctx)
+}
+
+// Stats returns how reads of both metrics and processes have been served so far.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:   c.stats.hits.Load(),
+		Misses: c.stats.misses.Load(),
+		Stale:  c.stats.stale.Load(),
+	}
+}
+
+// Unwrap returns the upstream source.
+func (c *Cache) Unwrap() source.MetricsSource {
+	return c.upstream
+}
+
+// get returns the cached value if it is fresh, otherwise waits for a shared refresh.
+// If the refresh fails, a previous value within MaxStale is returned and marked stale on the request report.
+// Annotations recorded while fetching a value are replayed onto the request report whenever it is served.
+func (e *entry[T]) get(ctx context.Context, opts Options, stats *counters, fetch func(context.Context) (T, error)) (T, error) {
+	rep := report.FromContext(ctx)
+
+	e.mu.Lock()
+	if e.valid && time.Since(e.fetchedAt) < opts.TTL {
+		value, valueReport := e.value, e.report
+		e.mu.Unlock()
+		stats.hits.Add(1)
+		rep.Merge(valueReport)
+		return value, nil
+	}
+	stats.misses.Add(1)
+
+	cl := e.inflight
+	if cl == nil {
+		cl = &call[T]{done: make(chan struct{})}
+		e.inflight = cl
+		// Detach from the caller's cancellation so one client going away does not fail the others
+		go e.refresh(context.WithoutCancel(ctx), opts.FetchTimeout, cl, fetch)
+	}
+	e.mu.Unlock()
+
+	select {
+	case <-cl.done:
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+
+	if cl.err == nil {
+		rep.Merge(cl.report)
+		return cl.value, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.valid && (opts.MaxStale <= 0 || time.Since(e.fetchedAt) < opts.MaxStale) {
+		rep.Merge(e.report)
+		rep.MarkStale(e.fetchedAt)
+		stats.stale.Add(1)
+		return e.value, nil
+	}
+
+	var zero T
+	return zero, cl.err
+}
+
+// refresh fetches a new value from the upstream and wakes every caller waiting on cl.
+func (e *entry[T]) refresh(ctx context.Context, timeout time.Duration, cl *call[T], fetch func(context.Context) (T, error)) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Collect annotations on a report of our own; ctx still carries the first caller's
+	ctx, rep := report.NewContext(ctx)
+	value, err := fetch(ctx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err == nil {
+		e.value = value
+		e.report = rep
+		e.fetchedAt = time.Now()
+		e.valid = true
+	}
+	e.inflight = nil
+
+	cl.value = value
+	cl.report = rep
+	cl.err = err
+	close(cl.done)
+}
@@ -0,0 +1,225 @@
+// Package exporter publishes data derived by the backend in the Prometheus text exposition format.
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/idle"
+	"k8s-gpu-monitoring/internal/redact"
+	"k8s-gpu-monitoring/internal/source"
+	"k8s-gpu-monitoring/internal/usage"
+)
+
+// Exporter collects derived series from a metrics source at scrape time.
+type Exporter struct {
+	source    source.MetricsSource
+	cache     *cache.Cache
+	queries   *QueryStats
+	redaction *redact.Policy
+}
+
+// Option configures optional Exporter behaviour.
+type Option func(*Exporter)
+
+// WithRedaction redacts the user label of per-user series as the policy redacts them for
+// unauthenticated callers, since scrapers are not identified.
+func WithRedaction(policy *redact.Policy) Option {
+	return func(e *Exporter) {
+		e.redaction = policy
+	}
+}
+
+// New creates a new exporter reading snapshots from src.
+// Cache and query series are only published when snapshots and queries are non-nil.
+func New(src source.MetricsSource, snapshots *cache.Cache, queries *QueryStats, opts ...Option) *Exporter {
+	e := &Exporter{
+		source:  src,
+		cache:   snapshots,
+		queries: queries,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// label is a single name="value" pair of a sample.
+type label struct {
+	name  string
+	value string
+}
+
+// sample is a single line of a metric family; suffix is appended to the family name (e.g. "_bucket").
+type sample struct {
+	suffix string
+	labels []label
+	value  float64
+}
+
+// WriteTo writes every series to w. Failing to read a snapshot is reported
+// through gpu_monitoring_snapshot_up instead of failing the scrape.
+func (e *Exporter) WriteTo(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	e.writeSnapshot(ctx, bw)
+	if e.cache != nil {
+		e.writeCache(bw)
+	}
+	if e.queries != nil {
+		e.writeQueries(bw)
+	}
+
+	return bw.Flush()
+}
+
+// writeSnapshot writes the series derived from the current GPU metrics and processes.
+func (e *Exporter) writeSnapshot(ctx context.Context, w io.Writer) {
+	const upHelp = "Whether the current GPU snapshot could be read."
+
+	metrics, err := e.source.GetGPUMetrics(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting GPU metrics for exporter", "error", err)
+		writeFamily(w, "gpu_monitoring_snapshot_up", upHelp, "gauge", []sample{{value: 0}})
+		return
+	}
+	processes, err := e.source.GetGPUProcesses(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting GPU processes for exporter", "error", err)
+		writeFamily(w, "gpu_monitoring_snapshot_up", upHelp, "gauge", []sample{{value: 0}})
+		return
+	}
+	writeFamily(w, "gpu_monitoring_snapshot_up", upHelp, "gauge", []sample{{value: 1}})
+
+	busy := make(map[string]bool)
+	for _, p := range processes {
+		busy[fmt.Sprintf("%s:%s:%d", p.Cluster, p.NodeName, p.GPUIndex)] = true
+	}
+
+	var free, lowUtilizationCount float64
+	for _, m := range metrics {
+		// A GPU whose utilization is unknown is counted as neither free nor low utilization
+		lowUtilization := m.GPUUtilization != nil && *m.GPUUtilization < idle.DefaultThreshold
+		if lowUtilization && !busy[fmt.Sprintf("%s:%s:%d", m.Cluster, m.NodeName, m.GPUIndex)] {
+			free++
+		}
+		if lowUtilization && m.GPUMemoryUsed != nil && *m.GPUMemoryUsed > 0 {
+			lowUtilizationCount++
+		}
+	}
+
+	writeFamily(w, "gpu_monitoring_gpus", "Number of GPUs reporting metrics.", "gauge", []sample{{value: float64(len(metrics))}})
+	writeFamily(w, "gpu_monitoring_free_gpus", "Number of GPUs without processes and with utilization below the idle threshold.", "gauge", []sample{{value: free}})
+	// Only the current utilization is known here; /api/v1/gpu/idle requires it to stay low over a window
+	writeFamily(w, "gpu_monitoring_low_utilization_gpus", "Number of GPUs holding memory with current utilization below the idle threshold.", "gauge", []sample{{value: lowUtilizationCount}})
+
+	var memory, gpus []sample
+	// Redacting before aggregating merges users masked to the same name into one series
+	for _, u := range usage.ByUser(e.redaction.Processes(nil, processes)) {
+		labels := []label{{"user", u.Name}}
+		memory = append(memory, sample{labels: labels, value: float64(u.GPUMemory)})
+		gpus = append(gpus, sample{labels: labels, value: float64(u.GPUCount)})
+	}
+	writeFamily(w, "gpu_monitoring_user_gpu_memory", "GPU memory held by the processes of each user.", "gauge", memory)
+	writeFamily(w, "gpu_monitoring_user_gpus", "Number of GPUs running processes of each user.", "gauge", gpus)
+}
+
+// writeCache writes the snapshot cache counters.
+func (e *Exporter) writeCache(w io.Writer) {
+	stats := e.cache.Stats()
+
+	writeFamily(w, "gpu_monitoring_cache_requests_total", "Snapshot cache reads by how they were served.", "counter", []sample{
+		{labels: []label{{"result", "hit"}}, value: float64(stats.Hits)},
+		{labels: []label{{"result", "miss"}}, value: float64(stats.Misses)},
+		{labels: []label{{"result", "stale"}}, value: float64(stats.Stale)},
+	})
+
+	ratio := 0.0
+	if total := stats.Hits + stats.Misses; total > 0 {
+		ratio = float64(stats.Hits) / float64(total)
+	}
+	writeFamily(w, "gpu_monitoring_cache_hit_ratio", "Fraction of snapshot cache reads served without contacting Prometheus.", "gauge", []sample{{value: ratio}})
+}
+
+// writeQueries writes the upstream query latency histograms and error counters.
+func (e *Exporter) writeQueries(w io.Writer) {
+	names, stats := e.queries.snapshot()
+
+	var latency, errors []sample
+	for i, name := range names {
+		stat := stats[i]
+		for j, bound := range latencyBuckets {
+			latency = append(latency, sample{
+				suffix: "_bucket",
+				labels: []label{{"query", name}, {"le", formatFloat(bound)}},
+				value:  float64(stat.buckets[j]),
+			})
+		}
+		latency = append(latency,
+			sample{suffix: "_bucket", labels: []label{{"query", name}, {"le", "+Inf"}}, value: float64(stat.count)},
+			sample{suffix: "_sum", labels: []label{{"query", name}}, value: stat.sum},
+			sample{suffix: "_count", labels: []label{{"query", name}}, value: float64(stat.count)},
+		)
+		errors = append(errors, sample{labels: []label{{"query", name}}, value: float64(stat.errors)})
+	}
+
+	writeFamily(w, "gpu_monitoring_upstream_query_duration_seconds", "Latency of Prometheus queries issued for GPU data.", "histogram", latency)
+	writeFamily(w, "gpu_monitoring_upstream_query_errors_total", "Failed Prometheus queries issued for GPU data.", "counter", errors)
+}
+
+// writeFamily writes the HELP and TYPE lines followed by the samples of one metric family.
+func writeFamily(w io.Writer, name, help, typ string, samples []sample) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+
+	for _, s := range samples {
+		var b strings.Builder
+		b.WriteString(name)
+		b.WriteString(s.suffix)
+		if len(s.labels) > 0 {
+			b.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(l.name)
+				b.WriteString(`="`)
+				b.WriteString(escapeLabelValue(l.value))
+				b.WriteByte('"')
+			}
+			b.WriteByte('}')
+		}
+		b.WriteByte(' ')
+		b.WriteString(formatFloat(s.value))
+		b.WriteByte('\n')
+		io.WriteString(w, b.String())
+	}
+}
+
+// labelValueEscaper escapes backslashes, double quotes and newlines as required by the text format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value for the text format.
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+// formatFloat formats a sample value for the text format.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds in seconds of the query latency histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// QueryStats records upstream query latency and errors per query name.
+// It implements prometheus.QueryObserver.
+type QueryStats struct {
+	mu      sync.Mutex
+	queries map[string]*queryStat
+}
+
+// queryStat is the histogram and error count of a single query name.
+type queryStat struct {
+	buckets []uint64 // cumulative counts per latencyBuckets entry
+	count   uint64
+	sum     float64
+	errors  uint64
+}
+
+// NewQueryStats creates a new empty query recorder.
+func NewQueryStats() *QueryStats {
+	return &QueryStats{
+		queries: make(map[string]*queryStat),
+	}
+}
+
+// ObserveQuery records a single query.
+func (s *QueryStats) ObserveQuery(name string, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat, ok := s.queries[name]
+	if !ok {
+		stat = &queryStat{buckets: make([]uint64, len(latencyBuckets))}
+		s.queries[name] = stat
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			stat.buckets[i]++
+		}
+	}
+	stat.count++
+	stat.sum += seconds
+	if err != nil {
+		stat.errors++
+	}
+}
+
+// snapshot returns a copy of the recorded stats sorted by query name.
+func (s *QueryStats) snapshot() ([]string, []queryStat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.queries))
+	for name := range s.queries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	stats := make([]queryStat, len(names))
+	for i, name := range names {
+		stat := *s.queries[name]
+		stat.buckets = slices.Clone(stat.buckets)
+		stats[i] = stat
+	}
+	return names, stats
+}
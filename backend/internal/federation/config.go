@@ -0,0 +1,90 @@
+package federation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+// Config lists the Prometheus servers to federate, read from a YAML or JSON file.
+type Config struct {
+	Clusters []ClusterConfig `json:"clusters" yaml:"clusters"`
+}
+
+// ClusterConfig names a cluster and the Prometheus server its GPU metrics are read from.
+// Connection settings left unset fall back to the PROMETHEUS_* environment settings.
+type ClusterConfig struct {
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url" yaml:"url"`
+
+	prometheus.TransportConfig `yaml:",inline"`
+}
+
+// clusterName restricts names to characters that are safe in query parameters and labels.
+var clusterName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// LoadConfig reads and validates a cluster list from path. Files ending in .json are parsed as JSON, others as YAML.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading clusters: %w", err)
+	}
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("parsing clusters %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid clusters %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate reports every problem in the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+	if len(c.Clusters) == 0 {
+		errs = append(errs, errors.New("at least one cluster is required"))
+	}
+
+	names := make(map[string]bool)
+	for i, cluster := range c.Clusters {
+		if !clusterName.MatchString(cluster.Name) {
+			errs = append(errs, fmt.Errorf("clusters[%d]: invalid name %q: use letters, digits, '_', '.' and '-'", i, cluster.Name))
+		} else if names[cluster.Name] {
+			errs = append(errs, fmt.Errorf("clusters[%d]: duplicate cluster name %q", i, cluster.Name))
+		}
+		names[cluster.Name] = true
+
+		u, err := url.Parse(cluster.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("clusters[%d]: invalid url %q: expected http(s)://host[:port]", i, cluster.URL))
+		}
+		if err := cluster.TransportConfig.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("clusters[%d]: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,195 @@
+// Package federation reads GPU data from several clusters, each behind its own
+// metrics source, and merges it into a single view tagged with the cluster name.
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// Cluster is a named member of a federation.
+type Cluster struct {
+	Name   string
+	Source source.MetricsSource
+}
+
+// Federation queries every cluster concurrently. A read succeeds while at least one
+// cluster answers; the outcome for each cluster is recorded on the request report.
+type Federation struct {
+	clusters []Cluster
+}
+
+var (
+	_ source.MetricsSource       = (*Federation)(nil)
+	_ source.HistorySource       = (*Federation)(nil)
+	_ source.UtilizationSource   = (*Federation)(nil)
+	_ source.ProcessWindowSource = (*Federation)(nil)
+	_ source.ClusterSource       = (*Federation)(nil)
+	_ source.CircuitSource       = (*Federation)(nil)
+)
+
+// New creates a federation of clusters.
+func New(clusters []Cluster) *Federation {
+	return &Federation{clusters: clusters}
+}
+
+// Clusters returns the names of the federated clusters in configuration order.
+func (f *Federation) Clusters() []string {
+	names := make([]string, 0, len(f.clusters))
+	for _, c := range f.clusters {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Circuits returns the circuit breakers of every cluster, tagged with the cluster name.
+func (f *Federation) Circuits() []models.CircuitStatus {
+	var circuits []models.CircuitStatus
+	for _, c := range f.clusters {
+		circuitSource, ok := source.As[source.CircuitSource](c.Source)
+		if !ok {
+			continue
+		}
+		for _, circuit := range circuitSource.Circuits() {
+			circuit.Cluster = c.Name
+			circuits = append(circuits, circuit)
+		}
+	}
+	return circuits
+}
+
+// GetGPUMetrics returns the metrics of every cluster that could be read.
+func (f *Federation) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	return gather(ctx, f.clusters,
+		func(ctx context.Context, src source.MetricsSource) ([]models.GPUMetrics, error) {
+			return src.GetGPUMetrics(ctx)
+		},
+		func(m *models.GPUMetrics, cluster string) { m.Cluster = cluster },
+	)
+}
+
+// GetGPUProcesses returns the processes of every cluster that could be read.
+func (f *Federation) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	return gather(ctx, f.clusters,
+		func(ctx context.Context, src source.MetricsSource) ([]models.GPUProcess, error) {
+			return src.GetGPUProcesses(ctx)
+		},
+		tagProcess,
+	)
+}
+
+// GetGPUProcessesOverWindow returns the processes seen within window on every cluster that could be read.
+func (f *Federation) GetGPUProcessesOverWindow(ctx context.Context, window time.Duration) ([]models.GPUProcess, error) {
+	return gather(ctx, f.clusters,
+		func(ctx context.Context, src source.MetricsSource) ([]models.GPUProcess, error) {
+			windowSource, ok := source.As[source.ProcessWindowSource](src)
+			if !ok {
+				return nil, source.ErrUnsupported
+			}
+			return windowSource.GetGPUProcessesOverWindow(ctx, window)
+		},
+		tagProcess,
+	)
+}
+
+// GetGPUUtilization returns the utilization of every cluster that could be read.
+func (f *Federation) GetGPUUtilization(ctx context.Context) ([]models.GPUUtilization, error) {
+	return gather(ctx, f.clusters,
+		func(ctx context.Context, src source.MetricsSource) ([]models.GPUUtilization, error) {
+			utilizationSource, ok := source.As[source.UtilizationSource](src)
+			if !ok {
+				return nil, source.ErrUnsupported
+			}
+			return utilizationSource.GetGPUUtilization(ctx)
+		},
+		func(u *models.GPUUtilization, cluster string) { u.Cluster = cluster },
+	)
+}
+
+// GetGPUMetricsHistory returns the series of every cluster that could be read.
+func (f *Federation) GetGPUMetricsHistory(ctx context.Context, q models.MetricsQuery) ([]models.GPUMetricsSeries, error) {
+	return gather(ctx, f.clusters,
+		func(ctx context.Context, src source.MetricsSource) ([]models.GPUMetricsSeries, error) {
+			historySource, ok := source.As[source.HistorySource](src)
+			if !ok {
+				return nil, source.ErrUnsupported
+			}
+			return historySource.GetGPUMetricsHistory(ctx, q)
+		},
+		func(s *models.GPUMetricsSeries, cluster string) { s.Cluster = cluster },
+	)
+}
+
+// Ping succeeds while at least one cluster is reachable.
+func (f *Federation) Ping(ctx context.Context) error {
+	_, err := gather(ctx, f.clusters,
+		func(ctx context.Context, src source.MetricsSource) ([]struct{}, error) {
+			return nil, src.Ping(ctx)
+		},
+		func(*struct{}, string) {},
+	)
+	return err
+}
+
+// tagProcess sets the cluster of a process.
+func tagProcess(p *models.GPUProcess, cluster string) {
+	p.Cluster = cluster
+}
+
+// gather calls fetch on every cluster concurrently and concatenates the results, tagging each item
+// with its cluster. Failed clusters are left out; an error is returned only when every cluster failed.
+func gather[T any](ctx context.Context, clusters []Cluster, fetch func(context.Context, source.MetricsSource) ([]T, error), tag func(*T, string)) ([]T, error) {
+	type result struct {
+		items  []T
+		report *report.Report
+		err    error
+	}
+	results := make([]result, len(clusters))
+
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each cluster collects its own annotations so they can be attributed to it
+			clusterCtx, rep := report.NewContext(ctx)
+			items, err := fetch(clusterCtx, cluster.Source)
+			results[i] = result{items: items, report: rep, err: err}
+		}()
+	}
+	wg.Wait()
+
+	rep := report.FromContext(ctx)
+	all := []T{}
+	var errs []error
+	for i, cluster := range clusters {
+		res := results[i]
+		rep.SetClusterStatus(cluster.Name, res.err)
+		if res.err != nil {
+			if !errors.Is(res.err, source.ErrUnsupported) {
+				slog.WarnContext(ctx, "Cluster unavailable", "cluster", cluster.Name, "error", res.err)
+			}
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cluster.Name, res.err))
+			continue
+		}
+
+		rep.MergeCluster(cluster.Name, res.report)
+		for _, item := range res.items {
+			tag(&item, cluster.Name)
+			all = append(all, item)
+		}
+	}
+
+	if len(errs) == len(clusters) {
+		return nil, errors.Join(errs...)
+	}
+	return all, nil
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"k8s-gpu-monitoring/internal/alerting"
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// AlertHandler serves the state of the alerting engine.
+type AlertHandler struct {
+	engine *alerting.Engine
+}
+
+// NewAlertHandler creates a new alert handler reporting alerts tracked by engine.
+func NewAlertHandler(engine *alerting.Engine) *AlertHandler {
+	return &AlertHandler{
+		engine: engine,
+	}
+}
+
+// GetAlerts handles GET /api/v1/alerts - returns pending and firing alerts.
+func (h *AlertHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	response := models.APIResponse{
+		Success: true,
+		Data:    h.engine.Alerts(),
+		Message: "Alerts retrieved successfully",
+	}
+
+	writeJSON(w, r, http.StatusOK, response)
+}
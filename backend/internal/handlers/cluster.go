@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// parseCluster reads the cluster URL parameter. An empty result selects every cluster.
+// Names not served by the source are rejected, including any name when the source is not federated.
+func (h *GPUHandler) parseCluster(r *http.Request) (string, error) {
+	name := r.URL.Query().Get("cluster")
+	if name == "" {
+		return "", nil
+	}
+
+	clusterSource, ok := source.As[source.ClusterSource](h.source)
+	if !ok || !slices.Contains(clusterSource.Clusters(), name) {
+		return "", fmt.Errorf("unknown cluster %q", name)
+	}
+	return name, nil
+}
+
+// filterCluster returns the items belonging to cluster, or all items when cluster is empty.
+func filterCluster[T any](items []T, cluster string, clusterOf func(T) string) []T {
+	if cluster == "" {
+		return items
+	}
+
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if clusterOf(item) == cluster {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// selectCluster narrows the cluster statuses and warnings of response to cluster.
+// Warnings not attributed to any cluster are kept.
+func selectCluster(response *models.APIResponse, cluster string) {
+	if cluster == "" {
+		return
+	}
+
+	response.Clusters = filterCluster(response.Clusters, cluster, func(c models.ClusterStatus) string { return c.Name })
+	response.Warnings = slices.DeleteFunc(response.Warnings, func(w models.Warning) bool {
+		return w.Cluster != "" && w.Cluster != cluster
+	})
+}
+
+// Cluster accessors used with filterCluster.
+func metricsCluster(m models.GPUMetrics) string         { return m.Cluster }
+func processCluster(p models.GPUProcess) string         { return p.Cluster }
+func utilizationCluster(u models.GPUUtilization) string { return u.Cluster }
+func seriesCluster(s models.GPUMetricsSeries) string    { return s.Cluster }
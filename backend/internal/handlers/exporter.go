@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"k8s-gpu-monitoring/internal/exporter"
+)
+
+// ExporterHandler serves the backend's derived data for Prometheus to scrape.
+type ExporterHandler struct {
+	exporter *exporter.Exporter
+}
+
+// NewExporterHandler creates a new handler serving series collected by exp.
+func NewExporterHandler(exp *exporter.Exporter) *ExporterHandler {
+	return &ExporterHandler{
+		exporter: exp,
+	}
+}
+
+// GetMetrics handles GET /metrics - returns series in the Prometheus text exposition format.
+func (h *ExporterHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if err := h.exporter.WriteTo(ctx, &buf); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing exporter metrics", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -3,112 +3,195 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"k8s-gpu-monitoring/internal/auth"
+	"k8s-gpu-monitoring/internal/logging"
 	"k8s-gpu-monitoring/internal/models"
-	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/redact"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
 	"k8s-gpu-monitoring/internal/timeutil"
 )
 
-// GPUHandler handles GPU-related HTTP requests with Prometheus backend.
+// GPUHandler handles GPU-related HTTP requests backed by a metrics source.
 type GPUHandler struct {
-	promClient *prometheus.Client
+	source    source.MetricsSource
+	redaction *redact.Policy
 }
 
-// NewGPUHandler creates a new GPU handler with the provided Prometheus client.
-func NewGPUHandler(promClient *prometheus.Client) *GPUHandler {
-	return &GPUHandler{
-		promClient: promClient,
+// GPUHandlerOption configures optional GPUHandler behaviour.
+type GPUHandlerOption func(*GPUHandler)
+
+// WithRedaction redacts process users and command lines according to the caller's groups.
+func WithRedaction(policy *redact.Policy) GPUHandlerOption {
+	return func(h *GPUHandler) {
+		h.redaction = policy
+	}
+}
+
+// NewGPUHandler creates a new GPU handler reading from the provided metrics source.
+func NewGPUHandler(src source.MetricsSource, opts ...GPUHandlerOption) *GPUHandler {
+	h := &GPUHandler{
+		source: src,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// callerGroups returns the groups of the authenticated caller, if any.
+func callerGroups(r *http.Request) []string {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	return principal.Groups
 }
 
-// writeJSONResponse writes a JSON response with proper headers.
-func (h *GPUHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+// writeJSON writes a JSON response with proper headers, with the timestamps of an
+// APIResponse in the format requested by r.
+func writeJSON(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	if response, ok := data.(models.APIResponse); ok {
+		localize(&response, timeutil.FromContext(r.Context()))
+		data = response
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		slog.ErrorContext(r.Context(), "Error encoding JSON response", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 }
 
 // writeErrorResponse writes a standardized error response.
-func (h *GPUHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+func (h *GPUHandler) writeErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
 	response := models.APIResponse{
-		Success: false,
-		Error:   message,
+		Success:   false,
+		Error:     message,
+		RequestID: logging.RequestID(r.Context()),
 	}
-	h.writeJSONResponse(w, statusCode, response)
+	writeJSON(w, r, statusCode, response)
+}
+
+// applyReport copies annotations collected while producing the data onto the response,
+// keeping only those of cluster when one is selected.
+func applyReport(response *models.APIResponse, rep *report.Report, cluster string) {
+	if stale, fetchedAt := rep.Stale(); stale {
+		response.Stale = true
+		response.FetchedAt = timeutil.FormatRFC3339(fetchedAt)
+	}
+	response.Warnings = rep.Warnings()
+	response.Clusters = rep.Clusters()
+	selectCluster(response, cluster)
 }
 
 // GetGPUMetrics handles GET /api/v1/gpu/metrics - returns comprehensive GPU metrics.
 func (h *GPUHandler) GetGPUMetrics(w http.ResponseWriter, r *http.Request) {
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	metrics, err := h.promClient.GetGPUMetrics(ctx)
+	ctx, rep := report.NewContext(ctx)
+
+	metrics, err := h.source.GetGPUMetrics(ctx)
 	if err != nil {
-		log.Printf("Error getting GPU metrics: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve GPU metrics")
+		slog.ErrorContext(r.Context(), "Error getting GPU metrics", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve GPU metrics")
 		return
 	}
 
 	response := models.APIResponse{
 		Success: true,
-		Data:    metrics,
+		Data:    filterCluster(metrics, cluster, metricsCluster),
 		Message: "GPU metrics retrieved successfully",
 	}
+	applyReport(&response, rep, cluster)
 
-	h.writeJSONResponse(w, http.StatusOK, response)
+	writeJSON(w, r, http.StatusOK, response)
 }
 
 // GetGPUProcesses handles GET /api/v1/gpu/processes - returns running GPU processes.
 func (h *GPUHandler) GetGPUProcesses(w http.ResponseWriter, r *http.Request) {
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	processes, err := h.promClient.GetGPUProcesses(ctx)
+	ctx, rep := report.NewContext(ctx)
+
+	processes, err := h.source.GetGPUProcesses(ctx)
 	if err != nil {
-		log.Printf("Error getting GPU processes: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve GPU processes")
+		slog.ErrorContext(r.Context(), "Error getting GPU processes", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve GPU processes")
 		return
 	}
 
 	response := models.APIResponse{
 		Success: true,
-		Data:    processes,
+		Data:    h.redaction.Processes(callerGroups(r), filterCluster(processes, cluster, processCluster)),
 		Message: "GPU processes retrieved successfully",
 	}
+	applyReport(&response, rep, cluster)
 
-	h.writeJSONResponse(w, http.StatusOK, response)
+	writeJSON(w, r, http.StatusOK, response)
 }
 
-// HealthCheck handles GET /api/healthz - verifies service and Prometheus connectivity.
+// HealthCheck handles GET /api/healthz - verifies service and metrics source connectivity.
 func (h *GPUHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	// Verify Prometheus server connectivity
+	// Verify metrics source connectivity
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	_, err := h.promClient.Query(ctx, "up")
+	// A federated source reports which clusters answered
+	ctx, rep := report.NewContext(ctx)
+
+	err := h.source.Ping(ctx)
+
+	data := map[string]interface{}{
+		"status":    "healthy",
+		"timestamp": timeutil.Now(),
+		"version":   "1.0.0",
+	}
+	// Circuit breakers explain why an unreachable upstream is no longer being queried
+	if circuitSource, ok := source.As[source.CircuitSource](h.source); ok {
+		if circuits := circuitSource.Circuits(); len(circuits) > 0 {
+			data["circuit_breakers"] = circuits
+		}
+	}
+
 	if err != nil {
-		log.Printf("Health check failed: %v", err)
-		h.writeErrorResponse(w, http.StatusServiceUnavailable, "Prometheus connection failed")
+		slog.WarnContext(r.Context(), "Health check failed", "error", err)
+		data["status"] = "unhealthy"
+		response := models.APIResponse{
+			Success:   false,
+			Data:      data,
+			Error:     "Metrics source connection failed",
+			RequestID: logging.RequestID(r.Context()),
+		}
+		applyReport(&response, rep, "")
+		writeJSON(w, r, http.StatusServiceUnavailable, response)
 		return
 	}
 
 	response := models.APIResponse{
 		Success: true,
 		Message: "Service is healthy",
-		Data: map[string]interface{}{
-			"status":    "healthy",
-			"timestamp": timeutil.NowJST(),
-			"version":   "1.0.0",
-		},
+		Data:    data,
 	}
+	applyReport(&response, rep, "")
 
-	h.writeJSONResponse(w, http.StatusOK, response)
+	writeJSON(w, r, http.StatusOK, response)
 }
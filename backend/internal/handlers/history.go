@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+const (
+	// defaultHistoryRange is the lookback used when no start time is given.
+	defaultHistoryRange = time.Hour
+	// defaultHistoryStep is the resolution used when no step is given.
+	defaultHistoryStep = time.Minute
+	// maxHistoryPoints mirrors the Prometheus limit on points per series in a range query.
+	maxHistoryPoints = 11000
+)
+
+// GetGPUMetricsHistory handles GET /api/v1/gpu/metrics/history - returns per-GPU time series.
+func (h *GPUHandler) GetGPUMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	historySource, ok := source.As[source.HistorySource](h.source)
+	if !ok {
+		h.writeErrorResponse(w, r, http.StatusNotImplemented, "GPU metrics history is not supported by the configured source")
+		return
+	}
+
+	query, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ctx, rep := report.NewContext(ctx)
+
+	history, err := historySource.GetGPUMetricsHistory(ctx, query)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting GPU metrics history", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve GPU metrics history")
+		return
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Data:    filterCluster(history, cluster, seriesCluster),
+		Message: "GPU metrics history retrieved successfully",
+	}
+	applyReport(&response, rep, cluster)
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// parseHistoryQuery builds a range query from the start, end, step, node and gpu URL parameters.
+func parseHistoryQuery(r *http.Request, now time.Time) (models.MetricsQuery, error) {
+	params := r.URL.Query()
+
+	end := now
+	if v := params.Get("end"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			return models.MetricsQuery{}, fmt.Errorf("invalid end: %w", err)
+		}
+		end = t
+	}
+
+	start := end.Add(-defaultHistoryRange)
+	if v := params.Get("start"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			return models.MetricsQuery{}, fmt.Errorf("invalid start: %w", err)
+		}
+		start = t
+	}
+
+	step := defaultHistoryStep
+	if v := params.Get("step"); v != "" {
+		d, err := parseDurationParam(v)
+		if err != nil {
+			return models.MetricsQuery{}, fmt.Errorf("invalid step: %w", err)
+		}
+		step = d
+	}
+
+	if !start.Before(end) {
+		return models.MetricsQuery{}, fmt.Errorf("start must be before end")
+	}
+	if step <= 0 {
+		return models.MetricsQuery{}, fmt.Errorf("step must be positive")
+	}
+	if end.Sub(start)/step > maxHistoryPoints {
+		return models.MetricsQuery{}, fmt.Errorf("range too large for step: more than %d points per series", maxHistoryPoints)
+	}
+
+	gpu := params.Get("gpu")
+	if gpu != "" {
+		if _, err := strconv.Atoi(gpu); err != nil {
+			return models.MetricsQuery{}, fmt.Errorf("invalid gpu: must be an integer index")
+		}
+	}
+
+	return models.MetricsQuery{
+		StartTime: strconv.FormatInt(start.Unix(), 10),
+		EndTime:   strconv.FormatInt(end.Unix(), 10),
+		Step:      strconv.FormatFloat(step.Seconds(), 'f', -1, 64),
+		Node:      params.Get("node"),
+		GPU:       gpu,
+	}, nil
+}
+
+// parseTimeParam parses an RFC3339 timestamp or Unix seconds.
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	sec, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 or Unix seconds, got %q", v)
+	}
+	return time.Unix(0, int64(sec*float64(time.Second))), nil
+}
+
+// parseDurationParam parses a Go duration (e.g. "30s", "5m") or a number of seconds.
+func parseDurationParam(v string) (time.Duration, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, nil
+	}
+	sec, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected duration or seconds, got %q", v)
+	}
+	return time.Duration(sec * float64(time.Second)), nil
+}
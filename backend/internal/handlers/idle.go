@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s-gpu-monitoring/internal/idle"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+const (
+	// defaultMinIdle is how long a GPU must have been idle to be reported.
+	defaultMinIdle = time.Hour
+	// defaultIdleWindow is how far back idle periods are looked for.
+	defaultIdleWindow = 6 * time.Hour
+	// idleWindowPoints is the number of history samples per GPU the window is split into.
+	idleWindowPoints = 360
+)
+
+// GetIdleGPUs handles GET /api/v1/gpu/idle - returns GPUs holding memory while idle and the processes on them.
+func (h *GPUHandler) GetIdleGPUs(w http.ResponseWriter, r *http.Request) {
+	historySource, ok := source.As[source.HistorySource](h.source)
+	if !ok {
+		h.writeErrorResponse(w, r, http.StatusNotImplemented, "Idle GPU detection is not supported by the configured source")
+		return
+	}
+
+	opts, window, err := parseIdleQuery(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ctx, rep := report.NewContext(ctx)
+
+	end := time.Now()
+	step := max(window/idleWindowPoints, time.Minute)
+	history, err := historySource.GetGPUMetricsHistory(ctx, models.MetricsQuery{
+		StartTime: strconv.FormatInt(end.Add(-window).Unix(), 10),
+		EndTime:   strconv.FormatInt(end.Unix(), 10),
+		Step:      strconv.FormatFloat(step.Seconds(), 'f', -1, 64),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting GPU metrics history for idle detection", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve idle GPUs")
+		return
+	}
+
+	processes, err := h.source.GetGPUProcesses(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting GPU processes for idle detection", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve idle GPUs")
+		return
+	}
+
+	idleGPUs, err := idle.Analyze(filterCluster(history, cluster, seriesCluster), filterCluster(processes, cluster, processCluster), opts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error analyzing idle GPUs", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve idle GPUs")
+		return
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Data:    h.redaction.IdleGPUs(callerGroups(r), idleGPUs),
+		Message: "Idle GPUs retrieved successfully",
+	}
+	applyReport(&response, rep, cluster)
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// parseIdleQuery reads the threshold, min_idle and window URL parameters.
+func parseIdleQuery(r *http.Request) (idle.Options, time.Duration, error) {
+	params := r.URL.Query()
+	opts := idle.Options{
+		Threshold: idle.DefaultThreshold,
+		MinIdle:   defaultMinIdle,
+	}
+	window := defaultIdleWindow
+
+	if v := params.Get("threshold"); v != "" {
+		threshold, err := strconv.Atoi(v)
+		if err != nil || threshold < 1 || threshold > 100 {
+			return idle.Options{}, 0, fmt.Errorf("invalid threshold: must be an integer percentage between 1 and 100")
+		}
+		opts.Threshold = threshold
+	}
+
+	if v := params.Get("min_idle"); v != "" {
+		d, err := parseDurationParam(v)
+		if err != nil {
+			return idle.Options{}, 0, fmt.Errorf("invalid min_idle: %w", err)
+		}
+		opts.MinIdle = d
+	}
+
+	if v := params.Get("window"); v != "" {
+		d, err := parseDurationParam(v)
+		if err != nil {
+			return idle.Options{}, 0, fmt.Errorf("invalid window: %w", err)
+		}
+		window = d
+	}
+
+	if opts.MinIdle <= 0 {
+		return idle.Options{}, 0, fmt.Errorf("min_idle must be positive")
+	}
+	if window < opts.MinIdle || window > maxUsageWindow {
+		return idle.Options{}, 0, fmt.Errorf("window must be between min_idle and %s", maxUsageWindow)
+	}
+
+	return opts, window, nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+	"k8s-gpu-monitoring/internal/summary"
+)
+
+// GetGPUNodes handles GET /api/v1/gpu/nodes - returns per-node GPU summaries.
+func (h *GPUHandler) GetGPUNodes(w http.ResponseWriter, r *http.Request) {
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ctx, rep := report.NewContext(ctx)
+
+	metrics, err := h.source.GetGPUMetrics(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting GPU metrics for node summary", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve GPU nodes")
+		return
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Data:    summary.ByNode(filterCluster(metrics, cluster, metricsCluster)),
+		Message: "GPU nodes retrieved successfully",
+	}
+	applyReport(&response, rep, cluster)
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// GetGPUUtilization handles GET /api/v1/gpu/utilization - returns GPU utilization only.
+// Sources that cannot read utilization alone fall back to the full metrics.
+func (h *GPUHandler) GetGPUUtilization(w http.ResponseWriter, r *http.Request) {
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ctx, rep := report.NewContext(ctx)
+
+	var utilization []models.GPUUtilization
+	if utilizationSource, ok := source.As[source.UtilizationSource](h.source); ok {
+		utilization, err = utilizationSource.GetGPUUtilization(ctx)
+	} else {
+		err = source.ErrUnsupported
+	}
+
+	if errors.Is(err, source.ErrUnsupported) {
+		var metrics []models.GPUMetrics
+		metrics, err = h.source.GetGPUMetrics(ctx)
+		utilization = utilizationFromMetrics(metrics)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting GPU utilization", "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve GPU utilization")
+		return
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Data:    filterCluster(utilization, cluster, utilizationCluster),
+		Message: "GPU utilization retrieved successfully",
+	}
+	applyReport(&response, rep, cluster)
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// utilizationFromMetrics extracts the utilization of each GPU from full metrics.
+func utilizationFromMetrics(metrics []models.GPUMetrics) []models.GPUUtilization {
+	utilization := make([]models.GPUUtilization, 0, len(metrics))
+	for _, m := range metrics {
+		utilization = append(utilization, models.GPUUtilization{
+			Cluster:        m.Cluster,
+			NodeName:       m.NodeName,
+			GPUIndex:       m.GPUIndex,
+			GPUName:        m.GPUName,
+			GPUUtilization: m.GPUUtilization,
+			Timestamp:      m.Timestamp,
+		})
+	}
+	return utilization
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"k8s-gpu-monitoring/internal/logging"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/stream"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// StreamHandler serves live GPU metrics as Server-Sent Events backed by a shared hub.
+type StreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new stream handler publishing snapshots from hub.
+func NewStreamHandler(hub *stream.Hub) *StreamHandler {
+	return &StreamHandler{
+		hub:       hub,
+		heartbeat: 15 * time.Second,
+	}
+}
+
+// StreamGPUMetrics handles GET /api/v1/gpu/metrics/stream - streams GPU metrics snapshots.
+func (h *StreamHandler) StreamGPUMetrics(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// The server write timeout would otherwise cut long-lived streams off
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.ErrorContext(r.Context(), "Error disabling write deadline for stream", "error", err)
+	}
+
+	sub := h.hub.Subscribe()
+	if sub == nil {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Streaming not supported", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case snapshot, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if err := writeSnapshotEvent(r.Context(), w, snapshot); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSnapshotEvent writes a snapshot as a "metrics" event carrying the standard API response.
+func writeSnapshotEvent(ctx context.Context, w http.ResponseWriter, snapshot stream.Snapshot) error {
+	response := models.APIResponse{
+		Success: true,
+		Data:    snapshot.Metrics,
+		Message: "GPU metrics retrieved successfully",
+	}
+	applyReport(&response, snapshot.Report, "")
+	if snapshot.Err != nil {
+		slog.ErrorContext(ctx, "Error getting GPU metrics for stream", "error", snapshot.Err)
+		response = models.APIResponse{
+			Success:   false,
+			Error:     "Failed to retrieve GPU metrics",
+			RequestID: logging.RequestID(ctx),
+		}
+	}
+
+	localize(&response, timeutil.FromContext(ctx))
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding stream event", "error", err)
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: metrics\ndata: %s\n\n", snapshot.Sequence, data)
+	return err
+}
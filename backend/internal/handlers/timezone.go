@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"slices"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// localize rewrites the timestamps of the response into the format requested by the caller.
+// Data is copied before being rewritten, as it may be shared with the snapshot cache.
+func localize(response *models.APIResponse, f timeutil.Format) {
+	response.FetchedAt = f.Convert(response.FetchedAt)
+
+	switch data := response.Data.(type) {
+	case []models.GPUMetrics:
+		response.Data = convertAll(data, func(m *models.GPUMetrics) {
+			m.Timestamp = f.Convert(m.Timestamp)
+		})
+	case []models.GPUProcess:
+		response.Data = convertAll(data, func(p *models.GPUProcess) {
+			p.Timestamp = f.Convert(p.Timestamp)
+		})
+	case []models.GPUUtilization:
+		response.Data = convertAll(data, func(u *models.GPUUtilization) {
+			u.Timestamp = f.Convert(u.Timestamp)
+		})
+	case []models.NodeSummary:
+		response.Data = convertAll(data, func(n *models.NodeSummary) {
+			n.Timestamp = f.Convert(n.Timestamp)
+		})
+	case []models.GPUMetricsSeries:
+		response.Data = convertAll(data, func(s *models.GPUMetricsSeries) {
+			s.Samples = convertAll(s.Samples, func(sample *models.GPUMetricsSample) {
+				sample.Timestamp = f.Convert(sample.Timestamp)
+			})
+		})
+	case []models.IdleGPU:
+		response.Data = convertAll(data, func(g *models.IdleGPU) {
+			g.IdleSince = f.Convert(g.IdleSince)
+			g.Processes = convertAll(g.Processes, func(p *models.GPUProcess) {
+				p.Timestamp = f.Convert(p.Timestamp)
+			})
+		})
+	case []models.Alert:
+		response.Data = convertAll(data, func(a *models.Alert) {
+			a.ActiveSince = f.Convert(a.ActiveSince)
+			a.FiredAt = f.Convert(a.FiredAt)
+			a.ResolvedAt = f.Convert(a.ResolvedAt)
+		})
+	case map[string]interface{}:
+		// The health check builds its data for each request
+		if timestamp, ok := data["timestamp"].(string); ok {
+			data["timestamp"] = f.Convert(timestamp)
+		}
+		if circuits, ok := data["circuit_breakers"].([]models.CircuitStatus); ok {
+			data["circuit_breakers"] = convertAll(circuits, func(c *models.CircuitStatus) {
+				c.RetryAt = f.Convert(c.RetryAt)
+			})
+		}
+	}
+}
+
+// convertAll returns a copy of items with convert applied to each.
+func convertAll[T any](items []T, convert func(*T)) []T {
+	if items == nil {
+		return nil
+	}
+	items = slices.Clone(items)
+	for i := range items {
+		convert(&items[i])
+	}
+	return items
+}
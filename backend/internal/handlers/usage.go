@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+	"k8s-gpu-monitoring/internal/usage"
+)
+
+// maxUsageWindow bounds the lookback of windowed usage queries.
+const maxUsageWindow = 7 * 24 * time.Hour
+
+// GetGPUUsageByUser handles GET /api/v1/gpu/usage/by-user - returns GPU usage aggregated per user.
+func (h *GPUHandler) GetGPUUsageByUser(w http.ResponseWriter, r *http.Request) {
+	groups := callerGroups(r)
+	h.getGPUUsage(w, r, "user", func(processes []models.GPUProcess) []models.GPUUsage {
+		// Redact before aggregating so masked users merge into one row, as on /metrics
+		return usage.ByUser(h.redaction.Processes(groups, processes))
+	})
+}
+
+// GetGPUUsageByNamespace handles GET /api/v1/gpu/usage/by-namespace - returns GPU usage aggregated per namespace.
+func (h *GPUHandler) GetGPUUsageByNamespace(w http.ResponseWriter, r *http.Request) {
+	h.getGPUUsage(w, r, "namespace", usage.ByNamespace)
+}
+
+// getGPUUsage aggregates the current processes, or those seen within the window parameter, with aggregate.
+func (h *GPUHandler) getGPUUsage(w http.ResponseWriter, r *http.Request, groupBy string, aggregate func([]models.GPUProcess) []models.GPUUsage) {
+	window, err := parseUsageWindow(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cluster, err := h.parseCluster(r)
+	if err != nil {
+		h.writeErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	ctx, rep := report.NewContext(ctx)
+
+	var processes []models.GPUProcess
+	if window > 0 {
+		windowSource, ok := source.As[source.ProcessWindowSource](h.source)
+		if !ok {
+			h.writeErrorResponse(w, r, http.StatusNotImplemented, "Windowed GPU usage is not supported by the configured source")
+			return
+		}
+		processes, err = windowSource.GetGPUProcessesOverWindow(ctx, window)
+	} else {
+		processes, err = h.source.GetGPUProcesses(ctx)
+	}
+	if errors.Is(err, source.ErrUnsupported) {
+		h.writeErrorResponse(w, r, http.StatusNotImplemented, "Windowed GPU usage is not supported by the configured source")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting GPU processes for usage", "group_by", groupBy, "error", err)
+		h.writeErrorResponse(w, r, http.StatusInternalServerError, "Failed to retrieve GPU usage")
+		return
+	}
+
+	response := models.APIResponse{
+		Success: true,
+		Data:    aggregate(filterCluster(processes, cluster, processCluster)),
+		Message: fmt.Sprintf("GPU usage by %s retrieved successfully", groupBy),
+	}
+	applyReport(&response, rep, cluster)
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// parseUsageWindow parses the optional window URL parameter; zero means current usage.
+func parseUsageWindow(r *http.Request) (time.Duration, error) {
+	v := r.URL.Query().Get("window")
+	if v == "" {
+		return 0, nil
+	}
+
+	window, err := parseDurationParam(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window: %w", err)
+	}
+	if window < time.Second || window > maxUsageWindow {
+		return 0, fmt.Errorf("window must be between 1s and %s", maxUsageWindow)
+	}
+	return window, nil
+}
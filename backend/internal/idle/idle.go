@@ -0,0 +1,118 @@
+// Package idle detects GPUs that hold memory without doing any work.
+package idle
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// DefaultThreshold is the utilization percentage below which a GPU counts as idle unless configured otherwise.
+const DefaultThreshold = 5
+
+// Options configures idle detection.
+type Options struct {
+	// Threshold is the utilization percentage below which a GPU counts as idle.
+	Threshold int
+	// MinIdle is how long a GPU must have been idle to be reported.
+	MinIdle time.Duration
+}
+
+// Analyze reports GPUs whose most recent samples all have utilization below the threshold
+// while memory is allocated, for at least MinIdle, together with the processes on them.
+// The idle duration is measured within the history, so a GPU idle for the whole range reports the range length.
+func Analyze(history []models.GPUMetricsSeries, processes []models.GPUProcess, opts Options) ([]models.IdleGPU, error) {
+	processMap := make(map[string][]models.GPUProcess)
+	for _, p := range processes {
+		key := fmt.Sprintf("%s:%s:%d", p.Cluster, p.NodeName, p.GPUIndex)
+		processMap[key] = append(processMap[key], p)
+	}
+
+	idleGPUs := []models.IdleGPU{}
+	for _, series := range history {
+		idleGPU, ok, err := analyzeSeries(series, opts)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		idleGPU.Processes = processMap[fmt.Sprintf("%s:%s:%d", series.Cluster, series.NodeName, series.GPUIndex)]
+		if idleGPU.Processes == nil {
+			idleGPU.Processes = []models.GPUProcess{}
+		}
+		idleGPUs = append(idleGPUs, idleGPU)
+	}
+
+	sort.Slice(idleGPUs, func(i, j int) bool {
+		if idleGPUs[i].IdleSeconds != idleGPUs[j].IdleSeconds {
+			return idleGPUs[i].IdleSeconds > idleGPUs[j].IdleSeconds
+		}
+		if idleGPUs[i].Cluster != idleGPUs[j].Cluster {
+			return idleGPUs[i].Cluster < idleGPUs[j].Cluster
+		}
+		if idleGPUs[i].NodeName != idleGPUs[j].NodeName {
+			return idleGPUs[i].NodeName < idleGPUs[j].NodeName
+		}
+		return idleGPUs[i].GPUIndex < idleGPUs[j].GPUIndex
+	})
+
+	return idleGPUs, nil
+}
+
+// analyzeSeries walks the samples of one GPU back from the latest while it stays idle.
+func analyzeSeries(series models.GPUMetricsSeries, opts Options) (models.IdleGPU, bool, error) {
+	if len(series.Samples) == 0 {
+		return models.IdleGPU{}, false, nil
+	}
+
+	latest := series.Samples[len(series.Samples)-1]
+	if !isIdle(latest, opts) {
+		return models.IdleGPU{}, false, nil
+	}
+
+	first := len(series.Samples) - 1
+	maxUtilization := *latest.GPUUtilization
+	for first > 0 && isIdle(series.Samples[first-1], opts) {
+		first--
+		maxUtilization = max(maxUtilization, *series.Samples[first].GPUUtilization)
+	}
+
+	since, err := timeutil.Parse(series.Samples[first].Timestamp)
+	if err != nil {
+		return models.IdleGPU{}, false, fmt.Errorf("invalid sample timestamp %q: %w", series.Samples[first].Timestamp, err)
+	}
+	until, err := timeutil.Parse(latest.Timestamp)
+	if err != nil {
+		return models.IdleGPU{}, false, fmt.Errorf("invalid sample timestamp %q: %w", latest.Timestamp, err)
+	}
+
+	idleFor := until.Sub(since)
+	if idleFor < opts.MinIdle {
+		return models.IdleGPU{}, false, nil
+	}
+
+	return models.IdleGPU{
+		Cluster:        series.Cluster,
+		NodeName:       series.NodeName,
+		GPUIndex:       series.GPUIndex,
+		GPUName:        series.GPUName,
+		GPUMemoryUsed:  *latest.GPUMemoryUsed,
+		MaxUtilization: maxUtilization,
+		IdleSince:      series.Samples[first].Timestamp,
+		IdleSeconds:    int64(idleFor.Seconds()),
+	}, true, nil
+}
+
+// isIdle reports whether a sample has memory allocated but utilization below the threshold.
+// A sample missing either value does not count as idle.
+func isIdle(sample models.GPUMetricsSample, opts Options) bool {
+	if sample.GPUMemoryUsed == nil || sample.GPUUtilization == nil {
+		return false
+	}
+	return *sample.GPUMemoryUsed > 0 && *sample.GPUUtilization < float64(opts.Threshold)
+}
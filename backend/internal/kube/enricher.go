@@ -0,0 +1,264 @@
+// Package kube attributes GPU processes to Kubernetes pods and workloads using kube-state-metrics.
+package kube
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// Querier executes instant PromQL queries.
+type Querier interface {
+	Query(ctx context.Context, query string) (*prometheus.PrometheusResponse, error)
+}
+
+// kube-state-metrics queries used to attribute processes to pods and workloads.
+const (
+	queryContainerInfo  = `kube_pod_container_info{container_id!=""}`
+	queryPodInfo        = `kube_pod_info`
+	queryReplicaSetInfo = `kube_replicaset_owner`
+	queryJobInfo        = `kube_job_owner`
+)
+
+// Enricher decorates a MetricsSource, attributing GPU processes to the Kubernetes
+// pod, container and owning workload using kube-state-metrics series.
+// Attribution is best effort: if the lookups fail, processes are returned as they are.
+type Enricher struct {
+	upstream source.MetricsSource
+	querier  Querier
+}
+
+var (
+	_ source.MetricsSource       = (*Enricher)(nil)
+	_ source.ProcessWindowSource = (*Enricher)(nil)
+	_ source.Wrapper             = (*Enricher)(nil)
+)
+
+// NewEnricher creates a new enricher reading kube-state-metrics through querier.
+func NewEnricher(upstream source.MetricsSource, querier Querier) *Enricher {
+	return &Enricher{
+		upstream: upstream,
+		querier:  querier,
+	}
+}
+
+// GetGPUMetrics returns the upstream metrics unchanged.
+func (e *Enricher) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	return e.upstream.GetGPUMetrics(ctx)
+}
+
+// GetGPUProcesses returns the upstream processes with namespace, pod, container and workload filled in.
+func (e *Enricher) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	processes, err := e.upstream.GetGPUProcesses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.enrich(ctx, processes), nil
+}
+
+// GetGPUProcessesOverWindow returns the processes seen within window with Kubernetes fields filled in.
+func (e *Enricher) GetGPUProcessesOverWindow(ctx context.Context, window time.Duration) ([]models.GPUProcess, error) {
+	windowSource, ok := source.As[source.ProcessWindowSource](e.upstream)
+	if !ok {
+		return nil, source.ErrUnsupported
+	}
+	processes, err := windowSource.GetGPUProcessesOverWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	return e.enrich(ctx, processes), nil
+}
+
+// enrich returns a copy of processes attributed to pods and workloads.
+// Pods that have gone away since are no longer in kube-state-metrics and stay unattributed.
+func (e *Enricher) enrich(ctx context.Context, processes []models.GPUProcess) []models.GPUProcess {
+	if len(processes) == 0 {
+		return processes
+	}
+
+	idx, err := e.loadIndex(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Error loading pod attribution", "error", err)
+		return processes
+	}
+
+	// Upstream results may be shared, so enrich a copy
+	enriched := make([]models.GPUProcess, len(processes))
+	copy(enriched, processes)
+	for i := range enriched {
+		idx.attribute(&enriched[i])
+	}
+
+	return enriched
+}
+
+// Ping checks the upstream source.
+func (e *Enricher) Ping(ctx context.Context) error {
+	return e.upstream.Ping(ctx)
+}
+
+// Unwrap returns the upstream source.
+func (e *Enricher) Unwrap() source.MetricsSource {
+	return e.upstream
+}
+
+// containerRef identifies a container within a pod.
+type containerRef struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// owner identifies the controller that owns an object.
+type owner struct {
+	kind string
+	name string
+}
+
+// index holds the kube-state-metrics lookups for a single enrichment pass.
+type index struct {
+	containers  map[string]containerRef // key: container ID without runtime prefix
+	podOwners   map[string]owner        // key: "namespace/pod"
+	rsOwners    map[string]owner        // key: "namespace/replicaset"
+	jobOwners   map[string]owner        // key: "namespace/job"
+	containerID []string                // sorted keys of containers for prefix lookups
+}
+
+// loadIndex runs the kube-state-metrics queries concurrently and builds the lookup index.
+func (e *Enricher) loadIndex(ctx context.Context) (*index, error) {
+	queries := map[string]string{
+		"container_info":  queryContainerInfo,
+		"pod_info":        queryPodInfo,
+		"replicaset_info": queryReplicaSetInfo,
+		"job_info":        queryJobInfo,
+	}
+
+	results := make(map[string]*prometheus.PrometheusResponse)
+	errors := make(chan error, len(queries))
+	var mu sync.Mutex
+
+	for name, query := range queries {
+		go func(name, query string) {
+			resp, err := e.querier.Query(ctx, query)
+			if err != nil {
+				errors <- fmt.Errorf("query %s failed: %w", name, err)
+				return
+			}
+			mu.Lock()
+			results[name] = resp
+			mu.Unlock()
+			errors <- nil
+		}(name, query)
+	}
+
+	for i := 0; i < len(queries); i++ {
+		if err := <-errors; err != nil {
+			return nil, err
+		}
+	}
+
+	idx := &index{
+		containers: make(map[string]containerRef),
+		podOwners:  make(map[string]owner),
+		rsOwners:   make(map[string]owner),
+		jobOwners:  make(map[string]owner),
+	}
+
+	for _, result := range results["container_info"].Data.Result {
+		id := trimRuntime(result.Metric["container_id"])
+		if id == "" {
+			continue
+		}
+		idx.containers[id] = containerRef{
+			namespace: result.Metric["namespace"],
+			pod:       result.Metric["pod"],
+			container: result.Metric["container"],
+		}
+		idx.containerID = append(idx.containerID, id)
+	}
+	slices.Sort(idx.containerID)
+
+	for _, result := range results["pod_info"].Data.Result {
+		key := result.Metric["namespace"] + "/" + result.Metric["pod"]
+		idx.podOwners[key] = owner{kind: result.Metric["created_by_kind"], name: result.Metric["created_by_name"]}
+	}
+
+	for _, result := range results["replicaset_info"].Data.Result {
+		key := result.Metric["namespace"] + "/" + result.Metric["replicaset"]
+		idx.rsOwners[key] = owner{kind: result.Metric["owner_kind"], name: result.Metric["owner_name"]}
+	}
+
+	for _, result := range results["job_info"].Data.Result {
+		key := result.Metric["namespace"] + "/" + result.Metric["job_name"]
+		idx.jobOwners[key] = owner{kind: result.Metric["owner_kind"], name: result.Metric["owner_name"]}
+	}
+
+	return idx, nil
+}
+
+// attribute fills the Kubernetes fields of proc from the index.
+func (idx *index) attribute(proc *models.GPUProcess) {
+	if proc.ContainerID != "" && (proc.Namespace == "" || proc.Pod == "") {
+		if ref, ok := idx.lookupContainer(trimRuntime(proc.ContainerID)); ok {
+			proc.Namespace = ref.namespace
+			proc.Pod = ref.pod
+			proc.Container = ref.container
+		}
+	}
+
+	if proc.Namespace == "" || proc.Pod == "" {
+		return
+	}
+
+	workload, ok := idx.podOwners[proc.Namespace+"/"+proc.Pod]
+	if !ok || workload.kind == "" || workload.kind == "<none>" {
+		return
+	}
+
+	// Resolve intermediate controllers to the workload users actually manage
+	switch workload.kind {
+	case "ReplicaSet":
+		if parent, ok := idx.rsOwners[proc.Namespace+"/"+workload.name]; ok && parent.kind != "" && parent.kind != "<none>" {
+			workload = parent
+		}
+	case "Job":
+		if parent, ok := idx.jobOwners[proc.Namespace+"/"+workload.name]; ok && parent.kind != "" && parent.kind != "<none>" {
+			workload = parent
+		}
+	}
+
+	proc.WorkloadKind = workload.kind
+	proc.WorkloadName = workload.name
+}
+
+// lookupContainer finds a container by full ID, or by the short ID prefix some exporters report.
+func (idx *index) lookupContainer(id string) (containerRef, bool) {
+	if ref, ok := idx.containers[id]; ok {
+		return ref, true
+	}
+	if len(id) < 12 {
+		return containerRef{}, false
+	}
+	// IDs starting with the prefix sort directly after it
+	i, _ := slices.BinarySearch(idx.containerID, id)
+	if i < len(idx.containerID) && strings.HasPrefix(idx.containerID[i], id) {
+		return idx.containers[idx.containerID[i]], true
+	}
+	return containerRef{}, false
+}
+
+// trimRuntime strips the runtime scheme from a container ID such as "containerd://abc".
+func trimRuntime(id string) string {
+	if i := strings.Index(id, "://"); i >= 0 {
+		return id[i+3:]
+	}
+	return id
+}
@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures which cross-origin requests are allowed.
+type CORSOptions struct {
+	// AllowedOrigins lists origins such as "https://dashboard.example.com". A "*." host prefix
+	// matches any subdomain, and "*" alone matches every origin. Empty allows no cross-origin requests.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD and OPTIONS.
+	AllowedMethods []string
+	// AllowedHeaders defaults to Content-Type, Authorization, X-Request-ID and Accept-Timezone.
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization headers; it cannot be combined with "*".
+	AllowCredentials bool
+	// MaxAge is how long preflight results may be cached. Defaults to 24 hours.
+	MaxAge time.Duration
+}
+
+// originPattern is a parsed AllowedOrigins entry.
+type originPattern struct {
+	any       bool
+	scheme    string
+	host      string
+	port      string
+	subdomain bool
+}
+
+// cors holds the compiled policy.
+type cors struct {
+	origins          []originPattern
+	wildcard         bool
+	methods          []string
+	headers          []string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORS creates a middleware that echoes allowed origins and rejects preflights from others.
+func NewCORS(opts CORSOptions) (func(http.Handler) http.Handler, error) {
+	c := &cors{
+		methods:          upper(opts.AllowedMethods),
+		headers:          canonical(opts.AllowedHeaders),
+		allowCredentials: opts.AllowCredentials,
+	}
+	if len(c.methods) == 0 {
+		c.methods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	}
+	if len(c.headers) == 0 {
+		c.headers = canonical([]string{"Content-Type", "Authorization", RequestIDHeader, TimezoneHeader})
+	}
+	maxAge := opts.MaxAge
+	if maxAge == 0 {
+		maxAge = 24 * time.Hour
+	}
+	c.maxAge = strconv.Itoa(int(maxAge.Seconds()))
+
+	var errs []error
+	for _, origin := range opts.AllowedOrigins {
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.wildcard = c.wildcard || pattern.any
+		c.origins = append(c.origins, pattern)
+	}
+	if c.wildcard && c.allowCredentials {
+		errs = append(errs, errors.New(`allowed origin "*" cannot be combined with credentials`))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return c.handler, nil
+}
+
+// handler applies the policy to next.
+func (c *cors) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// Responses differ by origin, so shared caches must not mix them up
+		w.Header().Add("Vary", "Origin")
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !c.allowed(origin) {
+			if preflight {
+				http.Error(w, "CORS origin not allowed", http.StatusForbidden)
+				return
+			}
+			// Without CORS headers the browser withholds the response from the page
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.allowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !slices.Contains(c.methods, strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))) {
+			http.Error(w, "CORS method not allowed", http.StatusForbidden)
+			return
+		}
+		for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+			if header != "" && !slices.Contains(c.headers, header) {
+				http.Error(w, "CORS header not allowed", http.StatusForbidden)
+				return
+			}
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.headers, ", "))
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowed reports whether origin matches any configured pattern.
+func (c *cors) allowed(origin string) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host, port := u.Hostname(), u.Port()
+
+	for _, p := range c.origins {
+		switch {
+		case p.any:
+			return true
+		case p.scheme != u.Scheme || p.port != port:
+			continue
+		case p.subdomain && strings.HasSuffix(host, "."+p.host):
+			return true
+		case !p.subdomain && host == p.host:
+			return true
+		}
+	}
+	return false
+}
+
+// parseOriginPattern parses an AllowedOrigins entry.
+func parseOriginPattern(origin string) (originPattern, error) {
+	origin = strings.ToLower(strings.TrimSpace(origin))
+	if origin == "*" {
+		return originPattern{any: true}, nil
+	}
+
+	scheme, rest, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return originPattern{}, fmt.Errorf("allowed origin %q: scheme must be http or https", origin)
+	}
+	subdomain := strings.HasPrefix(rest, "*.")
+	rest = strings.TrimPrefix(rest, "*.")
+
+	u, err := url.Parse(scheme + "://" + rest)
+	if err != nil || u.Hostname() == "" || strings.Contains(rest, "*") || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+		return originPattern{}, fmt.Errorf("allowed origin %q: expected scheme://host[:port]", origin)
+	}
+
+	return originPattern{
+		scheme:    scheme,
+		host:      u.Hostname(),
+		port:      u.Port(),
+		subdomain: subdomain,
+	}, nil
+}
+
+// upper upper-cases and trims method names, dropping empty ones.
+func upper(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// canonical canonicalizes and trims header names, dropping empty ones.
+func canonical(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, http.CanonicalHeaderKey(v))
+		}
+	}
+	return out
+}
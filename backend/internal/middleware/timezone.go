@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s-gpu-monitoring/internal/logging"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// TimezoneHeader lets callers ask for timestamps in their own time zone, like the tz query parameter.
+const TimezoneHeader = "Accept-Timezone"
+
+// Timezone middleware puts the time zone requested with the tz query parameter, or failing that the
+// Accept-Timezone header, into the request context. Unknown zones are rejected with 400, so it wraps
+// the API handlers that honor the zone rather than the whole server.
+func Timezone(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("tz")
+		if name == "" {
+			name = r.Header.Get(TimezoneHeader)
+		}
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		loc, err := timeutil.LoadLocation(name)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.APIResponse{
+				Success:   false,
+				Error:     err.Error(),
+				RequestID: logging.RequestID(r.Context()),
+			})
+			return
+		}
+
+		f := timeutil.Default()
+		f.Location = loc
+		next.ServeHTTP(w, r.WithContext(timeutil.NewContext(r.Context(), f)))
+	})
+}
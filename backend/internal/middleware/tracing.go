@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"k8s-gpu-monitoring/internal/logging"
+)
+
+// tracer returns the tracer for request spans from the current global provider.
+func tracer() trace.Tracer {
+	return otel.Tracer("k8s-gpu-monitoring/internal/middleware")
+}
+
+// Tracing returns a middleware that starts a server span per request, named after the route
+// matched in routes and continuing a trace from W3C trace-context headers.
+func Tracing(routes *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceRequest(w, r, routes, next)
+		})
+	}
+}
+
+// traceRequest serves r through next inside a server span.
+func traceRequest(w http.ResponseWriter, r *http.Request, routes *http.ServeMux, next http.Handler) {
+	// Name spans after the route so they stay low-cardinality
+	name := r.Method
+	_, route := routes.Handler(r)
+	if route != "" {
+		name = route
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("client.address", r.RemoteAddr),
+		),
+	)
+	defer span.End()
+
+	if route != "" {
+		span.SetAttributes(attribute.String("http.route", route))
+	}
+
+	if id := logging.RequestID(ctx); id != "" {
+		span.SetAttributes(attribute.StringSlice("http.request.header.x-request-id", []string{id}))
+	}
+
+	wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+	span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+	if wrapped.statusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+// Alert states reported by the alerting engine.
+const (
+	AlertStatePending  = "pending"
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+// Alert represents the state of an alert rule for a single GPU.
+type Alert struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity,omitempty"`
+	State    string `json:"state"`
+	Cluster  string `json:"cluster,omitempty"`
+	NodeName string `json:"node_name"`
+	GPUIndex int    `json:"gpu_index"`
+	GPUName  string `json:"gpu_name"`
+	Summary  string `json:"summary,omitempty"`
+	// Values holds the last observed value of each metric in the rule's conditions.
+	Values      map[string]float64 `json:"values"`
+	ActiveSince string             `json:"active_since"`
+	FiredAt     string             `json:"fired_at,omitempty"`
+	ResolvedAt  string             `json:"resolved_at,omitempty"`
+}
@@ -1,22 +1,64 @@
 package models
 
-// GPUMetrics represents GPU metrics data structure
+import "encoding/json"
+
+// APIVersion is the version of the response format, reported in every APIResponse.
+// Version 2 reports values that could not be read as null instead of 0 and keeps
+// utilization and temperature as fractional numbers.
+const APIVersion = 2
+
+// GPUMetrics represents GPU metrics data structure.
+// Values are nil, encoded as null, when their series could not be read.
 type GPUMetrics struct {
-	NodeName          string `json:"node_name"`
-	GPUIndex          int    `json:"gpu_index"`
-	GPUName           string `json:"gpu_name"`
-	GPUMemoryUsed     int    `json:"gpu_memory_used"`
-	GPUMemoryTotal    int    `json:"gpu_memory_total"`
-	GPUMemoryFree     int    `json:"memory_free"`
-	GPUUtilization    int    `json:"gpu_utilization"`
-	GPUTemperature    int    `json:"temperature"`
-	CPUUtilization    int    `json:"cpu_utilization"`
-	MemoryUtilization int    `json:"memory_utilization"`
-	Timestamp         string `json:"timestamp"`
+	// Cluster names the cluster the GPU belongs to when several clusters are federated.
+	Cluster           string   `json:"cluster,omitempty"`
+	NodeName          string   `json:"node_name"`
+	GPUIndex          int      `json:"gpu_index"`
+	GPUName           string   `json:"gpu_name"`
+	UUID              string   `json:"uuid,omitempty"`
+	GPUMemoryUsed     *int     `json:"gpu_memory_used"`
+	GPUMemoryTotal    *int     `json:"gpu_memory_total"`
+	GPUMemoryFree     *int     `json:"memory_free"`
+	GPUUtilization    *float64 `json:"gpu_utilization"`
+	GPUTemperature    *float64 `json:"temperature"`
+	CPUUtilization    *float64 `json:"cpu_utilization"`
+	MemoryUtilization *float64 `json:"memory_utilization"`
+	// Timestamp is when the GPU was last sampled; Stale is set when that is older than the staleness threshold,
+	// or when it is unknown and Timestamp is the query time
+	Timestamp string `json:"timestamp"`
+	Stale     bool   `json:"stale,omitempty"`
+}
+
+// GPUUtilization represents the utilization of a single GPU.
+type GPUUtilization struct {
+	Cluster        string   `json:"cluster,omitempty"`
+	NodeName       string   `json:"node_name"`
+	GPUIndex       int      `json:"gpu_index"`
+	GPUName        string   `json:"gpu_name"`
+	GPUUtilization *float64 `json:"gpu_utilization"`
+	Timestamp      string   `json:"timestamp"`
+}
+
+// NodeSummary represents the GPUs of a single node aggregated together with node-level utilization.
+// Aggregates cover the GPUs reporting each value and are nil when none do.
+type NodeSummary struct {
+	Cluster           string   `json:"cluster,omitempty"`
+	NodeName          string   `json:"node_name"`
+	GPUCount          int      `json:"gpu_count"`
+	GPUModels         []string `json:"gpu_models"`
+	GPUMemoryUsed     *int     `json:"gpu_memory_used"`
+	GPUMemoryTotal    *int     `json:"gpu_memory_total"`
+	GPUMemoryFree     *int     `json:"memory_free"`
+	GPUUtilization    *float64 `json:"gpu_utilization"`
+	MaxTemperature    *float64 `json:"max_temperature"`
+	CPUUtilization    *float64 `json:"cpu_utilization"`
+	MemoryUtilization *float64 `json:"memory_utilization"`
+	Timestamp         string   `json:"timestamp"`
 }
 
 // GPUProcess represents running GPU-related processes and their usage metrics.
 type GPUProcess struct {
+	Cluster     string `json:"cluster,omitempty"`
 	NodeName    string `json:"node_name"`
 	GPUIndex    int    `json:"gpu_index"`
 	PID         int    `json:"pid"`
@@ -24,15 +66,121 @@ type GPUProcess struct {
 	User        string `json:"user"`
 	Command     string `json:"command"`
 	GPUMemory   int    `json:"gpu_memory"`
-	Timestamp   string `json:"timestamp"`
+	// Kubernetes attribution, filled in when the owning pod can be determined
+	ContainerID  string `json:"container_id,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	Pod          string `json:"pod,omitempty"`
+	Container    string `json:"container,omitempty"`
+	WorkloadKind string `json:"workload_kind,omitempty"`
+	WorkloadName string `json:"workload_name,omitempty"`
+	Timestamp    string `json:"timestamp"`
+	Stale        bool   `json:"stale,omitempty"`
+}
+
+// GPUUsage represents the GPU usage of a single user or namespace aggregated over its processes.
+type GPUUsage struct {
+	Name         string `json:"name"`
+	GPUMemory    int    `json:"gpu_memory"`
+	GPUCount     int    `json:"gpu_count"`
+	ProcessCount int    `json:"process_count"`
+	// Unattributed is set on the group of processes without a user or namespace, named "unknown"
+	Unattributed bool `json:"-"`
+}
+
+// IdleGPU represents a GPU holding memory while its utilization stays below the idle threshold.
+type IdleGPU struct {
+	Cluster       string `json:"cluster,omitempty"`
+	NodeName      string `json:"node_name"`
+	GPUIndex      int    `json:"gpu_index"`
+	GPUName       string `json:"gpu_name"`
+	GPUMemoryUsed int    `json:"gpu_memory_used"`
+	// MaxUtilization is the highest utilization observed while idle.
+	MaxUtilization float64 `json:"max_utilization"`
+	IdleSince      string  `json:"idle_since"`
+	IdleSeconds    int64   `json:"idle_seconds"`
+	// Processes are the processes currently holding memory on the GPU.
+	Processes []GPUProcess `json:"processes"`
+}
+
+// GPUMetricsSeries represents the time series of a single GPU over a queried range.
+type GPUMetricsSeries struct {
+	Cluster  string             `json:"cluster,omitempty"`
+	NodeName string             `json:"node_name"`
+	GPUIndex int                `json:"gpu_index"`
+	GPUName  string             `json:"gpu_name"`
+	Samples  []GPUMetricsSample `json:"samples"`
+}
+
+// GPUMetricsSample represents GPU metrics at a single point in a time series.
+// Values are nil when their series has no point at the timestamp.
+type GPUMetricsSample struct {
+	Timestamp      string   `json:"timestamp"`
+	GPUUtilization *float64 `json:"gpu_utilization"`
+	GPUMemoryUsed  *int     `json:"gpu_memory_used"`
+	GPUTemperature *float64 `json:"temperature"`
 }
 
 // APIResponse represents standard API response structure
 type APIResponse struct {
+	// Version is always encoded as APIVersion.
+	Version int         `json:"version"`
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	// Stale is set when Data is the last known snapshot served because the upstream could not be reached.
+	Stale     bool   `json:"stale,omitempty"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	// Warnings lists the queries that failed when Data is partial.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// Clusters reports whether each federated cluster could be read.
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+	// RequestID identifies the request in server logs; set on error responses.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// MarshalJSON encodes the response stamped with the current APIVersion.
+func (r APIResponse) MarshalJSON() ([]byte, error) {
+	type plain APIResponse
+	r.Version = APIVersion
+	return json.Marshal(plain(r))
+}
+
+// Warning describes an upstream query whose data is missing from a partial response.
+type Warning struct {
+	// Cluster is set when the query was issued to one of several federated clusters.
+	Cluster string `json:"cluster,omitempty"`
+	Query   string `json:"query"`
+	Message string `json:"message"`
+}
+
+// Circuit breaker states reported in CircuitStatus.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitStatus reports the circuit breaker guarding an upstream Prometheus.
+type CircuitStatus struct {
+	Cluster             string `json:"cluster,omitempty"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	// RetryAt is when an open circuit lets a trial request through.
+	RetryAt string `json:"retry_at,omitempty"`
+}
+
+// Cluster statuses reported in ClusterStatus.
+const (
+	ClusterStatusOK    = "ok"
+	ClusterStatusError = "error"
+)
+
+// ClusterStatus reports the outcome of reading a single federated cluster.
+type ClusterStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
 // MetricsQuery represents Prometheus query parameters
@@ -41,4 +189,6 @@ type MetricsQuery struct {
 	StartTime string `json:"start_time,omitempty"`
 	EndTime   string `json:"end_time,omitempty"`
 	Step      string `json:"step,omitempty"`
+	Node      string `json:"node,omitempty"`
+	GPU       string `json:"gpu,omitempty"`
 }
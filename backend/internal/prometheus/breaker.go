@@ -0,0 +1,135 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// ErrCircuitOpen is returned without contacting Prometheus while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("prometheus circuit breaker is open")
+
+// BreakerOptions configures the circuit breaker guarding Prometheus.
+type BreakerOptions struct {
+	// Threshold is the number of consecutive failed requests that opens the circuit. Zero disables the breaker.
+	Threshold int
+	// Cooldown is how long the circuit stays open before a single trial request is let through.
+	Cooldown time.Duration
+}
+
+// WithCircuitBreaker fails requests fast once Prometheus has failed opts.Threshold times in a row,
+// so callers do not pile up waiting on an unavailable server. Only network errors, 429 and 5xx
+// responses count as failures; rejected queries and cancelled requests do not.
+func WithCircuitBreaker(opts BreakerOptions) Option {
+	return func(c *Client) {
+		if opts.Threshold > 0 {
+			c.breaker = &breaker{opts: opts, now: time.Now}
+		} else {
+			c.breaker = nil
+		}
+	}
+}
+
+// breaker is a consecutive-failure circuit breaker. A nil breaker lets every request through.
+type breaker struct {
+	opts BreakerOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// probing is set while the single half-open trial request is in flight
+	probing bool
+}
+
+// allow reports whether a request may be sent, returning ErrCircuitOpen if not.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case models.CircuitOpen:
+		retryAt := b.openedAt.Add(b.opts.Cooldown)
+		if b.now().Before(retryAt) {
+			return fmt.Errorf("%w: retrying after %s", ErrCircuitOpen, timeutil.FormatRFC3339(retryAt))
+		}
+		b.state = models.CircuitHalfOpen
+		b.probing = true
+		return nil
+	case models.CircuitHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: trial request in progress", ErrCircuitOpen)
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with the outcome err of an allowed request made with ctx.
+// Any response from Prometheus, even a rejected query, closes the circuit; cancelled requests and requests
+// not sent for lack of credentials are ignored.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == models.CircuitHalfOpen {
+		b.probing = false
+	}
+
+	var credential *credentialError
+	switch {
+	case ctx.Err() != nil, errors.As(err, &credential):
+		return
+	case upstreamFailure(ctx, err):
+		b.failures++
+		if b.state == models.CircuitHalfOpen || b.failures >= b.opts.Threshold {
+			b.state = models.CircuitOpen
+			b.openedAt = b.now()
+		}
+	default:
+		b.state = models.CircuitClosed
+		b.failures = 0
+	}
+}
+
+// status returns the current state of the breaker.
+func (b *breaker) status() models.CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := models.CircuitStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if status.State == "" {
+		status.State = models.CircuitClosed
+	}
+	if b.state == models.CircuitOpen {
+		status.RetryAt = timeutil.FormatRFC3339(b.openedAt.Add(b.opts.Cooldown))
+	}
+	return status
+}
+
+// Circuits returns the state of the client's circuit breaker, or nothing when it has none.
+func (c *Client) Circuits() []models.CircuitStatus {
+	if c.breaker == nil {
+		return nil
+	}
+	return []models.CircuitStatus{c.breaker.status()}
+}
@@ -3,19 +3,62 @@ package prometheus
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/source"
 )
 
 // Client represents a Prometheus HTTP API client.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	schema     Schema
+	observer   QueryObserver
+	retry      RetryOptions
+	breaker    *breaker
+	// staleThreshold is the sample age beyond which rows are flagged stale; zero disables the flag.
+	staleThreshold time.Duration
+	// combinedDisabled is set once the server rejects combined metric queries.
+	combinedDisabled atomic.Bool
+}
+
+// QueryObserver is notified of every named query issued for GPU metrics and processes.
+type QueryObserver interface {
+	ObserveQuery(name string, duration time.Duration, err error)
+}
+
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithSchema selects the exporter schema the client reads metrics from.
+func WithSchema(schema Schema) Option {
+	return func(c *Client) {
+		c.schema = schema
+	}
+}
+
+// WithObserver reports the latency and outcome of each named query to observer.
+func WithObserver(observer QueryObserver) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
 }
 
 // PrometheusResponse represents the response structure from Prometheus API.
@@ -32,52 +75,218 @@ type PrometheusResponse struct {
 	ErrorType string `json:"errorType,omitempty"`
 }
 
-// NewClient creates a new Prometheus client.
-func NewClient(baseURL string) *Client {
-	return &Client{
+// PrometheusRangeResponse represents the matrix response structure from the Prometheus range query API.
+type PrometheusRangeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+	Error     string `json:"error,omitempty"`
+	ErrorType string `json:"errorType,omitempty"`
+}
+
+// sampleValue parses the value of a sample given as [timestamp, "value"].
+// ok is false for NaN and infinite values, which carry no data.
+func sampleValue(point []interface{}) (value float64, ok bool, err error) {
+	if len(point) < 2 {
+		return 0, false, fmt.Errorf("malformed sample %v", point)
+	}
+	valueStr, isString := point[1].(string)
+	if !isString {
+		return 0, false, fmt.Errorf("malformed sample value %v", point[1])
+	}
+	value, err = strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid sample value %q", valueStr)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false, nil
+	}
+	return value, true, nil
+}
+
+var (
+	_ source.MetricsSource       = (*Client)(nil)
+	_ source.HistorySource       = (*Client)(nil)
+	_ source.ProcessWindowSource = (*Client)(nil)
+	_ source.UtilizationSource   = (*Client)(nil)
+	_ source.CircuitSource       = (*Client)(nil)
+)
+
+// NewClient creates a new Prometheus client reading the custom exporter schema unless configured otherwise.
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
 		baseURL: strings.TrimSuffix(baseURL, "/"),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		schema: SchemaCustom,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// tracer returns the tracer for Prometheus query spans from the current global provider.
+func tracer() trace.Tracer {
+	return otel.Tracer("k8s-gpu-monitoring/internal/prometheus")
 }
 
 // Query executes a PromQL query.
 func (c *Client) Query(ctx context.Context, query string) (*PrometheusResponse, error) {
-	queryURL := fmt.Sprintf("%s/api/v1/query", c.baseURL)
+	return c.query(ctx, "", query)
+}
+
+// query executes an instant query in a span tagged with name, the schema field it reads, when set.
+func (c *Client) query(ctx context.Context, name, query string) (_ *PrometheusResponse, err error) {
+	spanName := "prometheus.query"
+	if name != "" {
+		spanName += " " + name
+	}
+	ctx, span := startSpan(ctx, spanName, query)
+	if name != "" {
+		span.SetAttributes(attribute.String("prometheus.query.name", name))
+	}
+	defer func() { endSpan(span, err) }()
 
 	params := url.Values{}
 	params.Add("query", query)
 	params.Add("time", strconv.FormatInt(time.Now().Unix(), 10))
 
-	req, err := http.NewRequestWithContext(ctx, "GET", queryURL+"?"+params.Encode(), nil)
+	body, err := c.get(ctx, "/api/v1/query", params)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+	var promResp PrometheusResponse
+	if err := json.Unmarshal(body, &promResp); err != nil {
+		return nil, fmt.Errorf("unmarshaling response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if promResp.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s - %s", promResp.ErrorType, promResp.Error)
+	}
+	span.SetAttributes(attribute.Int("prometheus.result.size", len(promResp.Data.Result)))
+
+	return &promResp, nil
+}
+
+// namedQuery executes query, logging failures and reporting it to the observer under name, the schema field it reads.
+func (c *Client) namedQuery(ctx context.Context, name, query string) (*PrometheusResponse, error) {
+	start := time.Now()
+	resp, err := c.query(ctx, name, query)
+	duration := time.Since(start)
+
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		level := slog.LevelError
+		if ctx.Err() != nil {
+			// Cancelled alongside a sibling query that failed first, or the caller went away
+			level = slog.LevelDebug
+		}
+		slog.Log(ctx, level, "Prometheus query failed", "query", name, "duration", duration, "error", err)
+	}
+	if c.observer != nil {
+		c.observer.ObserveQuery(name, duration, err)
 	}
+	return resp, err
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus API error: status %d, body: %s", resp.StatusCode, string(body))
+// Ping verifies Prometheus connectivity with a trivial query.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Query(ctx, "up")
+	return err
+}
+
+// QueryRange executes a PromQL range query using the start, end and step of q.
+func (c *Client) QueryRange(ctx context.Context, q models.MetricsQuery) (_ *PrometheusRangeResponse, err error) {
+	ctx, span := startSpan(ctx, "prometheus.query_range", q.Query)
+	defer func() { endSpan(span, err) }()
+
+	params := url.Values{}
+	params.Add("query", q.Query)
+	params.Add("start", q.StartTime)
+	params.Add("end", q.EndTime)
+	params.Add("step", q.Step)
+
+	body, err := c.get(ctx, "/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
 	}
 
-	var promResp PrometheusResponse
+	var promResp PrometheusRangeResponse
 	if err := json.Unmarshal(body, &promResp); err != nil {
 		return nil, fmt.Errorf("unmarshaling response: %w", err)
 	}
 
 	if promResp.Status != "success" {
-		return nil, fmt.Errorf("prometheus query failed: %s - %s", promResp.ErrorType, promResp.Error)
+		return nil, fmt.Errorf("prometheus range query failed: %s - %s", promResp.ErrorType, promResp.Error)
 	}
+	span.SetAttributes(attribute.Int("prometheus.result.size", len(promResp.Data.Result)))
 
 	return &promResp, nil
 }
+
+// startSpan starts a client span for a PromQL query.
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system.name", "prometheus"), attribute.String("db.query.text", query)),
+	)
+}
+
+// endSpan records err on the span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// get performs a GET request against the Prometheus API and returns the raw response body,
+// going through the circuit breaker and retrying transient failures when configured.
+func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	body, err := c.getWithRetry(ctx, path, params.Encode())
+	c.breaker.record(ctx, err)
+	return body, err
+}
+
+// do performs a single GET request and returns the raw response body.
+func (c *Client) do(ctx context.Context, path, query string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path+"?"+query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	// Let Prometheus join the caller's trace
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Unwrap the *url.Error so a missing credential is not mistaken for an unreachable Prometheus
+		var credential *credentialError
+		if errors.As(err, &credential) {
+			return nil, credential
+		}
+		return nil, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, body: string(body)}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,91 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// sampleTimeQueryName labels the query reading when the series of a row were last sampled.
+const sampleTimeQueryName = "sample_time"
+
+// sampleTimeLabel marks the series of a combined query that carry sample times instead of values.
+const sampleTimeLabel = "gpu_monitoring_sample_time"
+
+// sampleTimeFields lists the per-GPU fields in order of preference for dating GPU rows.
+var sampleTimeFields = []string{
+	FieldGPUUtilization,
+	FieldGPUTemperature,
+	FieldGPUMemoryUsed,
+	FieldGPUMemoryTotal,
+	FieldGPUMemoryFree,
+}
+
+// WithStaleThreshold flags GPU metrics and processes last sampled more than threshold ago as stale,
+// so a node whose exporter stopped reporting stands out. Zero disables the flag.
+func WithStaleThreshold(threshold time.Duration) Option {
+	return func(c *Client) {
+		c.staleThreshold = threshold
+	}
+}
+
+// sampleTimeField returns the first field of candidates mapped to a plain metric name, or "" if there is none.
+// timestamp() only reports sample times for plain series, not for the result of an expression.
+func (c *Client) sampleTimeField(fields map[string]Field, candidates []string) string {
+	for _, name := range candidates {
+		if metricNamePattern.MatchString(fields[name].Query) {
+			return name
+		}
+	}
+	return ""
+}
+
+// sampleTimeQuery returns the query reading when each series of field was last sampled. Instant query
+// results carry the evaluation time instead, which says nothing about the age of the data.
+func sampleTimeQuery(field Field) string {
+	return fmt.Sprintf("timestamp(%s)", field.Query)
+}
+
+// sampleTimes reads when each series of field was last sampled, keyed by key applied to its labels.
+func (c *Client) sampleTimes(ctx context.Context, field Field, key func(map[string]string) string) (map[string]time.Time, error) {
+	resp, err := c.namedQuery(ctx, sampleTimeQueryName, sampleTimeQuery(field))
+	if err != nil {
+		return nil, err
+	}
+	return parseSampleTimes(resp, key), nil
+}
+
+// parseSampleTimes returns the latest sample time of each key in a response to a sampleTimeQuery.
+func parseSampleTimes(resp *PrometheusResponse, key func(map[string]string) string) map[string]time.Time {
+	times := make(map[string]time.Time, len(resp.Data.Result))
+	for _, result := range resp.Data.Result {
+		value, ok, err := sampleValue(result.Value)
+		if err != nil || !ok {
+			continue
+		}
+		k := key(result.Metric)
+		if t := unixFloatToTime(value); t.After(times[k]) {
+			times[k] = t
+		}
+	}
+	return times
+}
+
+// freshness returns the timestamp and stale flag of the row keyed key. times is nil when rows cannot be
+// dated, as the schema has no plain metric to read sample times from or the query is a window aggregate.
+// Otherwise rows missing from times, because their series has no sample time or the sample times could
+// not be read, carry queriedAt and are flagged stale, as nothing shows they are current.
+func (c *Client) freshness(times map[string]time.Time, key string, queriedAt time.Time) (string, bool) {
+	t, ok := times[key]
+	if !ok {
+		return timeutil.FormatRFC3339(queriedAt), times != nil && c.staleThreshold > 0
+	}
+	return timeutil.FormatRFC3339(t), c.isStale(t)
+}
+
+// isStale reports whether data sampled at t is older than the staleness threshold.
+func (c *Client) isStale(t time.Time) bool {
+	return c.staleThreshold > 0 && time.Since(t) > c.staleThreshold
+}
@@ -0,0 +1,186 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// GetGPUMetricsHistory retrieves per-GPU time series for the range described by q.
+// The Node and GPU fields of q narrow the result to a single node and/or GPU index.
+func (c *Client) GetGPUMetricsHistory(ctx context.Context, q models.MetricsQuery) ([]models.GPUMetricsSeries, error) {
+	queries := make(map[string]string)
+	for _, name := range []string{FieldGPUUtilization, FieldGPUMemoryUsed, FieldGPUTemperature} {
+		if field := c.schema.Metrics[name]; field.Query != "" {
+			queries[name] = withMatchers(field.Query, historyMatchers(c.schema.labels(field), q.Node, q.GPU))
+		}
+	}
+
+	results := make(map[string]*PrometheusRangeResponse)
+	errors := make(chan error, len(queries))
+	var mu sync.Mutex
+
+	for name, query := range queries {
+		go func(name, query string) {
+			rangeQuery := q
+			rangeQuery.Query = query
+			resp, err := c.QueryRange(ctx, rangeQuery)
+			if err != nil {
+				errors <- fmt.Errorf("range query %s failed: %w", name, err)
+				return
+			}
+			mu.Lock()
+			results[name] = resp
+			mu.Unlock()
+			errors <- nil
+		}(name, query)
+	}
+
+	for i := 0; i < len(queries); i++ {
+		if err := <-errors; err != nil {
+			return nil, err
+		}
+	}
+
+	return c.parseGPUMetricsHistory(ctx, results, q)
+}
+
+// historyMatchers builds PromQL label matchers for the optional node and GPU filters.
+func historyMatchers(labels Labels, node, gpu string) []string {
+	var matchers []string
+	if node != "" {
+		matchers = append(matchers, labels.Node+"="+strconv.Quote(node))
+	}
+	if gpu != "" {
+		matchers = append(matchers, labels.GPU+"="+strconv.Quote(gpu))
+	}
+	return matchers
+}
+
+// parseGPUMetricsHistory parses Prometheus range responses into per-GPU series matching the filters of q.
+// Samples with unparsable values are skipped and recorded as warnings on the request report.
+func (c *Client) parseGPUMetricsHistory(ctx context.Context, results map[string]*PrometheusRangeResponse, q models.MetricsQuery) ([]models.GPUMetricsSeries, error) {
+	rep := report.FromContext(ctx)
+	type seriesEntry struct {
+		series  models.GPUMetricsSeries
+		samples map[float64]*models.GPUMetricsSample
+	}
+	seriesMap := make(map[string]*seriesEntry) // key: "node_name:gpu_index"
+
+	for metricType, response := range results {
+		if response == nil {
+			continue
+		}
+		labels := c.schema.labels(c.schema.Metrics[metricType])
+
+		for _, result := range response.Data.Result {
+			nodeName := result.Metric[labels.Node]
+			gpuIndex := result.Metric[labels.GPU]
+
+			if nodeName == "" || gpuIndex == "" {
+				continue
+			}
+
+			// Expressions that could not carry matchers are filtered here
+			if (q.Node != "" && nodeName != q.Node) || (q.GPU != "" && gpuIndex != q.GPU) {
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%s", nodeName, gpuIndex)
+
+			entry, exists := seriesMap[key]
+			if !exists {
+				idx, _ := strconv.Atoi(gpuIndex)
+				entry = &seriesEntry{
+					series: models.GPUMetricsSeries{
+						NodeName: nodeName,
+						GPUIndex: idx,
+						GPUName:  result.Metric[labels.GPUName],
+					},
+					samples: make(map[float64]*models.GPUMetricsSample),
+				}
+				seriesMap[key] = entry
+			}
+
+			for _, point := range result.Values {
+				value, ok, err := sampleValue(point)
+				if err != nil {
+					rep.AddWarning(metricType, fmt.Errorf("node %s gpu %s: %w", nodeName, gpuIndex, err))
+					continue
+				}
+				if !ok {
+					continue
+				}
+
+				ts, ok := point[0].(float64)
+				if !ok {
+					rep.AddWarning(metricType, fmt.Errorf("node %s gpu %s: malformed sample time %v", nodeName, gpuIndex, point[0]))
+					continue
+				}
+
+				var memory *int
+				if metricType == FieldGPUMemoryUsed {
+					if memory = intPtr(value); memory == nil {
+						rep.AddWarning(metricType, fmt.Errorf("node %s gpu %s: memory %v out of range", nodeName, gpuIndex, value))
+						continue
+					}
+				}
+
+				sample, exists := entry.samples[ts]
+				if !exists {
+					sample = &models.GPUMetricsSample{
+						Timestamp: timeutil.FormatRFC3339(unixFloatToTime(ts)),
+					}
+					entry.samples[ts] = sample
+				}
+
+				switch metricType {
+				case FieldGPUUtilization:
+					sample.GPUUtilization = &value
+				case FieldGPUMemoryUsed:
+					sample.GPUMemoryUsed = memory
+				case FieldGPUTemperature:
+					sample.GPUTemperature = &value
+				}
+			}
+		}
+	}
+
+	history := make([]models.GPUMetricsSeries, 0, len(seriesMap))
+	for _, entry := range seriesMap {
+		timestamps := make([]float64, 0, len(entry.samples))
+		for ts := range entry.samples {
+			timestamps = append(timestamps, ts)
+		}
+		sort.Float64s(timestamps)
+
+		entry.series.Samples = make([]models.GPUMetricsSample, 0, len(timestamps))
+		for _, ts := range timestamps {
+			entry.series.Samples = append(entry.series.Samples, *entry.samples[ts])
+		}
+		history = append(history, entry.series)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].NodeName != history[j].NodeName {
+			return history[i].NodeName < history[j].NodeName
+		}
+		return history[i].GPUIndex < history[j].GPUIndex
+	})
+
+	return history, nil
+}
+
+// unixFloatToTime converts a Prometheus sample timestamp in fractional seconds to time.Time.
+func unixFloatToTime(ts float64) time.Time {
+	sec := int64(ts)
+	nsec := int64((ts - float64(sec)) * 1e9)
+	return time.Unix(sec, nsec)
+}
@@ -2,98 +2,269 @@ package prometheus
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"k8s-gpu-monitoring/internal/models"
-	"k8s-gpu-monitoring/internal/timeutil"
+	"k8s-gpu-monitoring/internal/report"
 )
 
-// GetGPUMetrics retrieves GPU metrics from Prometheus with concurrent queries.
+// combinedQueryName labels the query that reads every plain metric of the schema at once.
+const combinedQueryName = "combined"
+
+// errNoMetricName is returned when a combined query yields series whose metric name was dropped.
+var errNoMetricName = errors.New("combined query returned series without __name__")
+
+// GetGPUMetrics retrieves GPU metrics from Prometheus, reading all fields mapped to plain metric
+// names and their sample times in a single query and the remaining expressions with concurrent queries.
+// A failed query leaves its field nil and is recorded as a warning on the request report;
+// an error is returned only when no per-GPU query succeeded.
 func (c *Client) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
-	// Execute multiple queries concurrently
-	queries := map[string]string{
-		"gpu_mem_free":       `gpu_metrics_free_memory`,
-		"gpu_mem_used":       `gpu_metrics_used_memory`,
-		"gpu_mem_total":      `gpu_metrics_total_memory`,
-		"gpu_utilization":    `gpu_metrics_utilization_percent`,
-		"gpu_temperature":    `gpu_metrics_temperature`,
-		"cpu_utilization":    `gpu_metrics_cpu_utilization`,
-		"memory_utilization": `gpu_metrics_memory_utilization`,
+	var names []string
+	for _, name := range metricFields {
+		if c.schema.Metrics[name].Query != "" {
+			names = append(names, name)
+		}
 	}
 
+	timeField := c.sampleTimeField(c.schema.Metrics, sampleTimeFields)
+	responses, errs := c.queryMetrics(ctx, names, timeField)
+
 	results := make(map[string]*PrometheusResponse)
-	errors := make(chan error, len(queries))
+	var failures []error
+	gpuQueries := 0
+	rep := report.FromContext(ctx)
+	for _, name := range names {
+		if err := errs[name]; err != nil {
+			failures = append(failures, fmt.Errorf("query %s failed: %w", name, err))
+			rep.AddWarning(name, err)
+			continue
+		}
+		results[name] = responses[name]
+		if !nodeFields[name] {
+			gpuQueries++
+		}
+	}
+
+	// Node-level values alone cannot be attributed to any GPU
+	if gpuQueries == 0 && len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+
+	var times map[string]time.Time
+	if timeField != "" {
+		if err := errs[sampleTimeQueryName]; err != nil {
+			// Every row is left undated and flagged stale
+			times = map[string]time.Time{}
+			rep.AddWarning(sampleTimeQueryName, err)
+		} else {
+			labels := c.schema.labels(c.schema.Metrics[timeField])
+			times = parseSampleTimes(responses[sampleTimeQueryName], func(metric map[string]string) string {
+				return fmt.Sprintf("%s:%s", metric[labels.Node], metric[labels.GPU])
+			})
+		}
+	}
+
+	return c.parseGPUMetrics(ctx, results, times)
+}
+
+// queryMetrics runs the queries of the named fields concurrently, returning the response or error of each.
+// Unless timeField is "", the sample times of that field are returned too, under sampleTimeQueryName.
+// Fields mapped to plain metric names share one combined query, which also reads the sample times when
+// it covers timeField; if the backend rejects it they are queried one by one, and the combined form is
+// not tried again by this client.
+func (c *Client) queryMetrics(ctx context.Context, names []string, timeField string) (map[string]*PrometheusResponse, map[string]error) {
+	var combined, separate []string
+	for _, name := range names {
+		if !c.combinedDisabled.Load() && metricNamePattern.MatchString(c.schema.Metrics[name].Query) {
+			combined = append(combined, name)
+		} else {
+			separate = append(separate, name)
+		}
+	}
+	if len(combined) < 2 {
+		// Nothing to save
+		separate, combined = names, nil
+	}
+
+	// The sample times are read separately unless the combined query reads them
+	var combinedTimeField string
+	if slices.Contains(combined, timeField) {
+		combinedTimeField = timeField
+	}
+
+	responses := make(map[string]*PrometheusResponse, len(names)+1)
+	errs := make(map[string]error, len(names)+1)
 	var mu sync.Mutex
+	var wg sync.WaitGroup
 
-	// Execute queries concurrently
-	for name, query := range queries {
-		go func(name, query string) {
-			resp, err := c.Query(ctx, query)
-			if err != nil {
-				errors <- fmt.Errorf("query %s failed: %w", name, err)
+	query := func(name, expr string) {
+		defer wg.Done()
+		resp, err := c.namedQuery(ctx, name, expr)
+		mu.Lock()
+		defer mu.Unlock()
+		responses[name], errs[name] = resp, err
+	}
+	queryField := func(name string) {
+		query(name, c.schema.Metrics[name].Query)
+	}
+	querySampleTimes := func() {
+		query(sampleTimeQueryName, sampleTimeQuery(c.schema.Metrics[timeField]))
+	}
+
+	for _, name := range separate {
+		wg.Add(1)
+		go queryField(name)
+	}
+	if timeField != "" && combinedTimeField == "" {
+		wg.Add(1)
+		go querySampleTimes()
+	}
+
+	if len(combined) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			split, err := c.combinedQuery(ctx, combined, combinedTimeField)
+			if err == nil {
+				mu.Lock()
+				maps.Copy(responses, split)
+				mu.Unlock()
+				return
+			}
+
+			if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) || upstreamFailure(ctx, err) {
+				// Separate queries would fail the same way, multiplying the load on a struggling backend
+				mu.Lock()
+				for _, name := range combined {
+					errs[name] = err
+				}
+				if combinedTimeField != "" {
+					errs[sampleTimeQueryName] = err
+				}
+				mu.Unlock()
 				return
 			}
-			mu.Lock()
-			results[name] = resp
-			mu.Unlock()
-			errors <- nil
-		}(name, query)
+			if c.combinedDisabled.CompareAndSwap(false, true) {
+				slog.WarnContext(ctx, "Prometheus rejected the combined metrics query, falling back to one query per field", "error", err)
+			}
+			for _, name := range combined {
+				wg.Add(1)
+				go queryField(name)
+			}
+			if combinedTimeField != "" {
+				wg.Add(1)
+				go querySampleTimes()
+			}
+		}()
 	}
 
-	// Wait for all queries to complete
-	for i := 0; i < len(queries); i++ {
-		if err := <-errors; err != nil {
-			return nil, err
+	wg.Wait()
+	return responses, errs
+}
+
+// combinedQuery reads the plain metrics of the named fields with one {__name__=~"..."} selector
+// and splits the series into a response per field by their metric name. Unless timeField is "",
+// the sample times of that field are read in the same query, marked by sampleTimeLabel as
+// timestamp() drops the metric name, and returned under sampleTimeQueryName.
+func (c *Client) combinedQuery(ctx context.Context, names []string, timeField string) (map[string]*PrometheusResponse, error) {
+	fields := make(map[string][]string)
+	var metrics []string
+	for _, name := range names {
+		metric := c.schema.Metrics[name].Query
+		if _, exists := fields[metric]; !exists {
+			metrics = append(metrics, metric)
 		}
+		fields[metric] = append(fields[metric], name)
 	}
 
-	return c.parseGPUMetrics(results)
+	query := fmt.Sprintf(`{__name__=~"%s"}`, strings.Join(metrics, "|"))
+	if timeField != "" {
+		// The added label keeps "or" from dropping the sample times as duplicates of the values
+		query += fmt.Sprintf(` or label_replace(%s, %q, "true", "", "")`, sampleTimeQuery(c.schema.Metrics[timeField]), sampleTimeLabel)
+		fields[sampleTimeLabel] = []string{sampleTimeQueryName}
+	}
+
+	resp, err := c.namedQuery(ctx, combinedQueryName, query)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(map[string]*PrometheusResponse, len(names)+1)
+	for _, targets := range fields {
+		for _, name := range targets {
+			split := &PrometheusResponse{Status: resp.Status}
+			split.Data.ResultType = resp.Data.ResultType
+			responses[name] = split
+		}
+	}
+	for _, result := range resp.Data.Result {
+		metric := result.Metric["__name__"]
+		if result.Metric[sampleTimeLabel] != "" {
+			metric = sampleTimeLabel
+		}
+		targets, ok := fields[metric]
+		if !ok {
+			return nil, errNoMetricName
+		}
+		for _, name := range targets {
+			responses[name].Data.Result = append(responses[name].Data.Result, result)
+		}
+	}
+
+	return responses, nil
 }
 
-// parseGPUMetrics parses Prometheus response into GPUMetrics.
-func (c *Client) parseGPUMetrics(results map[string]*PrometheusResponse) ([]models.GPUMetrics, error) {
+// parseGPUMetrics parses Prometheus response into GPUMetrics, dating each GPU by its entry in times,
+// keyed by "node_name:gpu_index", as described by freshness.
+// Samples with unparsable values are skipped and recorded as warnings on the request report.
+func (c *Client) parseGPUMetrics(ctx context.Context, results map[string]*PrometheusResponse, times map[string]time.Time) ([]models.GPUMetrics, error) {
+	rep := report.FromContext(ctx)
 	// Group metrics by node and GPU index
 	metricsMap := make(map[string]models.GPUMetrics) // key: "node_name:gpu_index"
+	queriedAt := time.Now()
 	// Store node-level CPU/Memory utilization
 	nodeUtilization := make(map[string]struct {
-		cpuUtilization    float64
-		memoryUtilization float64
+		cpuUtilization    *float64
+		memoryUtilization *float64
 	})
 
 	for metricType, response := range results {
+		labels := c.schema.labels(c.schema.Metrics[metricType])
 		for _, result := range response.Data.Result {
-			nodeName := result.Metric["hostname"]
-			gpuIndex := result.Metric["gpu_id"]
-			gpuName := result.Metric["gpu_name"]
+			nodeName := result.Metric[labels.Node]
+			gpuIndex := result.Metric[labels.GPU]
+			gpuName := result.Metric[labels.GPUName]
 
 			if nodeName == "" {
 				continue
 			}
 
 			// Parse and extract value
-			if len(result.Value) < 2 {
+			value, ok, err := sampleValue(result.Value)
+			if err != nil {
+				rep.AddWarning(metricType, fmt.Errorf("node %s: %w", nodeName, err))
 				continue
 			}
-
-			valueStr, ok := result.Value[1].(string)
 			if !ok {
 				continue
 			}
 
-			value, err := strconv.ParseFloat(valueStr, 64)
-			if err != nil {
-				continue
-			}
-
 			// Handle node-level metrics (cpu_utilization, memory_utilization)
-			if metricType == "cpu_utilization" || metricType == "memory_utilization" {
+			if metricType == FieldCPUUtilization || metricType == FieldMemoryUtilization {
 				util := nodeUtilization[nodeName]
-				if metricType == "cpu_utilization" {
-					util.cpuUtilization = value
+				if metricType == FieldCPUUtilization {
+					util.cpuUtilization = &value
 				} else {
-					util.memoryUtilization = value
+					util.memoryUtilization = &value
 				}
 				nodeUtilization[nodeName] = util
 				continue
@@ -103,31 +274,42 @@ func (c *Client) parseGPUMetrics(results map[string]*PrometheusResponse) ([]mode
 				continue
 			}
 
+			var memory *int
+			switch metricType {
+			case FieldGPUMemoryFree, FieldGPUMemoryUsed, FieldGPUMemoryTotal:
+				if memory = intPtr(value); memory == nil {
+					rep.AddWarning(metricType, fmt.Errorf("node %s gpu %s: memory %v out of range", nodeName, gpuIndex, value))
+					continue
+				}
+			}
+
 			key := fmt.Sprintf("%s:%s", nodeName, gpuIndex)
 
 			metricsEntry, exists := metricsMap[key]
 			if !exists {
 				idx, _ := strconv.Atoi(gpuIndex)
 				metricsEntry = models.GPUMetrics{
-					NodeName:  nodeName,
-					GPUIndex:  idx,
-					GPUName:   gpuName,
-					Timestamp: timeutil.NowJST(),
+					NodeName: nodeName,
+					GPUIndex: idx,
+					GPUName:  gpuName,
 				}
 			}
+			if uuid := result.Metric[labels.UUID]; uuid != "" {
+				metricsEntry.UUID = uuid
+			}
 
 			// Set value based on metric type
 			switch metricType {
-			case "gpu_mem_free":
-				metricsEntry.GPUMemoryFree = int(value)
-			case "gpu_mem_used":
-				metricsEntry.GPUMemoryUsed = int(value)
-			case "gpu_mem_total":
-				metricsEntry.GPUMemoryTotal = int(value)
-			case "gpu_utilization":
-				metricsEntry.GPUUtilization = int(value)
-			case "gpu_temperature":
-				metricsEntry.GPUTemperature = int(value)
+			case FieldGPUMemoryFree:
+				metricsEntry.GPUMemoryFree = memory
+			case FieldGPUMemoryUsed:
+				metricsEntry.GPUMemoryUsed = memory
+			case FieldGPUMemoryTotal:
+				metricsEntry.GPUMemoryTotal = memory
+			case FieldGPUUtilization:
+				metricsEntry.GPUUtilization = &value
+			case FieldGPUTemperature:
+				metricsEntry.GPUTemperature = &value
 			}
 
 			metricsMap[key] = metricsEntry
@@ -138,10 +320,12 @@ func (c *Client) parseGPUMetrics(results map[string]*PrometheusResponse) ([]mode
 	for key, metricsEntry := range metricsMap {
 		nodeName := metricsEntry.NodeName
 		if util, exists := nodeUtilization[nodeName]; exists {
-			metricsEntry.CPUUtilization = int(util.cpuUtilization)
-			metricsEntry.MemoryUtilization = int(util.memoryUtilization)
-			metricsMap[key] = metricsEntry
+			metricsEntry.CPUUtilization = util.cpuUtilization
+			metricsEntry.MemoryUtilization = util.memoryUtilization
 		}
+
+		metricsEntry.Timestamp, metricsEntry.Stale = c.freshness(times, key, queriedAt)
+		metricsMap[key] = metricsEntry
 	}
 
 	// Convert to slice
@@ -152,3 +336,21 @@ func (c *Client) parseGPUMetrics(results map[string]*PrometheusResponse) ([]mode
 
 	return gpuMetrics, nil
 }
+
+// intValue converts value to an int, or returns false if it is negative or does not fit.
+func intValue(value float64) (int, bool) {
+	if value < 0 || value >= math.MaxInt {
+		return 0, false
+	}
+	return int(value), true
+}
+
+// intPtr converts a sample value to an int, used for byte counts, and returns a pointer to it,
+// or nil if intValue rejects it.
+func intPtr(value float64) *int {
+	v, ok := intValue(value)
+	if !ok {
+		return nil
+	}
+	return &v
+}
@@ -6,15 +6,61 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"k8s-gpu-monitoring/internal/models"
-	"k8s-gpu-monitoring/internal/timeutil"
+	"k8s-gpu-monitoring/internal/report"
 )
 
 // GetGPUProcesses retrieves running GPU processes from Prometheus.
 func (c *Client) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
-	queries := map[string]string{
-		"gpu_memory": `gpu_process_gpu_memory`,
+	return c.queryGPUProcesses(ctx, nil)
+}
+
+// GetGPUProcessesOverWindow retrieves every GPU process seen within window, with the peak value of each metric.
+func (c *Client) GetGPUProcessesOverWindow(ctx context.Context, window time.Duration) ([]models.GPUProcess, error) {
+	if window < time.Second {
+		return nil, fmt.Errorf("window must be at least 1s")
+	}
+	rangeSelector := strconv.FormatInt(int64(window.Seconds()), 10) + "s"
+	return c.queryGPUProcesses(ctx, func(query string) string {
+		return fmt.Sprintf("max_over_time((%s)[%s:])", query, rangeSelector)
+	})
+}
+
+// queryGPUProcesses runs the process queries of the schema, each rewritten by wrap, and merges the results.
+// Without wrap the processes currently reported are read and dated by when they were last sampled.
+func (c *Client) queryGPUProcesses(ctx context.Context, wrap func(string) string) ([]models.GPUProcess, error) {
+	queries := make(map[string]string)
+	for name, field := range c.schema.Processes {
+		if field.Query == "" {
+			continue
+		}
+		if wrap != nil {
+			queries[name] = wrap(field.Query)
+		} else {
+			queries[name] = field.Query
+		}
+	}
+
+	// Window aggregates leave rows undated: their values may come from any point in the window
+	var times map[string]time.Time
+	var timesErr error
+	var wg sync.WaitGroup
+	// A failed value query returns early: cancel the sample time query and wait for it so it does not
+	// outlive the call. Deferred calls run in reverse, so cancel comes first.
+	defer wg.Wait()
+	timesCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if name := c.sampleTimeField(c.schema.Processes, processFields); wrap == nil && name != "" {
+		labels := c.schema.labels(c.schema.Processes[name])
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			times, timesErr = c.sampleTimes(timesCtx, c.schema.Processes[name], func(metric map[string]string) string {
+				return fmt.Sprintf("%s:%s:%s", metric[labels.Node], metric[labels.GPU], metric[labels.PID])
+			})
+		}()
 	}
 
 	results := make(map[string]*PrometheusResponse)
@@ -23,7 +69,7 @@ func (c *Client) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, erro
 
 	for name, query := range queries {
 		go func(name, query string) {
-			resp, err := c.Query(ctx, query)
+			resp, err := c.namedQuery(ctx, name, query)
 			if err != nil {
 				errors <- fmt.Errorf("query %s failed: %w", name, err)
 				return
@@ -41,22 +87,34 @@ func (c *Client) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, erro
 		}
 	}
 
-	return c.parseGPUProcesses(results)
+	wg.Wait()
+	if timesErr != nil {
+		// Every process is left undated and flagged stale
+		times = map[string]time.Time{}
+		report.FromContext(ctx).AddWarning(sampleTimeQueryName, timesErr)
+	}
+
+	return c.parseGPUProcesses(ctx, results, times)
 }
 
-// parseGPUProcesses parses Prometheus response into GPUProcess slice.
-func (c *Client) parseGPUProcesses(results map[string]*PrometheusResponse) ([]models.GPUProcess, error) {
+// parseGPUProcesses parses Prometheus response into GPUProcess slice, dating each process by its entry
+// in times, keyed by "node_name:gpu_index:pid", as described by freshness.
+// Samples with unparsable or out-of-range values are skipped and recorded as warnings on the request report.
+func (c *Client) parseGPUProcesses(ctx context.Context, results map[string]*PrometheusResponse, times map[string]time.Time) ([]models.GPUProcess, error) {
+	rep := report.FromContext(ctx)
 	processMap := make(map[string]models.GPUProcess)
+	queriedAt := time.Now()
 
 	for metricType, response := range results {
 		if response == nil {
 			continue
 		}
+		labels := c.schema.labels(c.schema.Processes[metricType])
 
 		for _, result := range response.Data.Result {
-			nodeName := result.Metric["hostname"]
-			gpuIndex := result.Metric["gpu_id"]
-			pidStr := result.Metric["pid"]
+			nodeName := result.Metric[labels.Node]
+			gpuIndex := result.Metric[labels.GPU]
+			pidStr := result.Metric[labels.PID]
 
 			if nodeName == "" || gpuIndex == "" || pidStr == "" {
 				continue
@@ -73,30 +131,32 @@ func (c *Client) parseGPUProcesses(results map[string]*PrometheusResponse) ([]mo
 					NodeName:    nodeName,
 					GPUIndex:    idx,
 					PID:         pid,
-					ProcessName: result.Metric["process_name"],
-					User:        result.Metric["user"],
-					Command:     result.Metric["command"],
-					Timestamp:   timeutil.NowJST(),
+					ProcessName: result.Metric[labels.ProcessName],
+					User:        result.Metric[labels.User],
+					Command:     result.Metric[labels.Command],
+					ContainerID: result.Metric[labels.ContainerID],
+					Namespace:   result.Metric[labels.Namespace],
+					Pod:         result.Metric[labels.Pod],
+					Container:   result.Metric[labels.Container],
 				}
 			}
-
-			if len(result.Value) < 2 {
+			value, ok, err := sampleValue(result.Value)
+			if err != nil {
+				rep.AddWarning(metricType, fmt.Errorf("node %s pid %s: %w", nodeName, pidStr, err))
 				continue
 			}
-
-			valueStr, ok := result.Value[1].(string)
 			if !ok {
 				continue
 			}
 
-			value, err := strconv.ParseFloat(valueStr, 64)
-			if err != nil {
-				continue
-			}
-
 			switch metricType {
-			case "gpu_memory":
-				proc.GPUMemory = int(value)
+			case FieldProcessGPUMemory:
+				memory, ok := intValue(value)
+				if !ok {
+					rep.AddWarning(metricType, fmt.Errorf("node %s pid %s: memory %v out of range", nodeName, pidStr, value))
+					continue
+				}
+				proc.GPUMemory = memory
 			}
 
 			processMap[key] = proc
@@ -108,7 +168,8 @@ func (c *Client) parseGPUProcesses(results map[string]*PrometheusResponse) ([]mo
 	}
 
 	processes := make([]models.GPUProcess, 0, len(processMap))
-	for _, proc := range processMap {
+	for key, proc := range processMap {
+		proc.Timestamp, proc.Stale = c.freshness(times, key, queriedAt)
 		processes = append(processes, proc)
 	}
 
@@ -0,0 +1,72 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// GetGPUUtilization retrieves GPU utilization from Prometheus with a single query.
+func (c *Client) GetGPUUtilization(ctx context.Context) ([]models.GPUUtilization, error) {
+	field := c.schema.Metrics[FieldGPUUtilization]
+	if field.Query == "" {
+		return nil, source.ErrUnsupported
+	}
+
+	resp, err := c.namedQuery(ctx, FieldGPUUtilization, field.Query)
+	if err != nil {
+		return nil, fmt.Errorf("query %s failed: %w", FieldGPUUtilization, err)
+	}
+
+	return c.parseGPUUtilization(ctx, resp, c.schema.labels(field)), nil
+}
+
+// parseGPUUtilization parses Prometheus response into GPUUtilization sorted by node and GPU index.
+// Samples with unparsable values are skipped and recorded as warnings on the request report.
+func (c *Client) parseGPUUtilization(ctx context.Context, resp *PrometheusResponse, labels Labels) []models.GPUUtilization {
+	utilization := []models.GPUUtilization{}
+	// Unlike GPU metrics, utilization rows are not dated by their last sample
+	queriedAt := time.Now()
+
+	for _, result := range resp.Data.Result {
+		nodeName := result.Metric[labels.Node]
+		gpuIndex := result.Metric[labels.GPU]
+		if nodeName == "" || gpuIndex == "" {
+			continue
+		}
+
+		value, ok, err := sampleValue(result.Value)
+		if err != nil {
+			report.FromContext(ctx).AddWarning(FieldGPUUtilization, fmt.Errorf("node %s: %w", nodeName, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		idx, _ := strconv.Atoi(gpuIndex)
+		utilization = append(utilization, models.GPUUtilization{
+			NodeName:       nodeName,
+			GPUIndex:       idx,
+			GPUName:        result.Metric[labels.GPUName],
+			GPUUtilization: &value,
+			Timestamp:      timeutil.FormatRFC3339(queriedAt),
+		})
+	}
+
+	sort.Slice(utilization, func(i, j int) bool {
+		if utilization[i].NodeName != utilization[j].NodeName {
+			return utilization[i].NodeName < utilization[j].NodeName
+		}
+		return utilization[i].GPUIndex < utilization[j].GPUIndex
+	})
+
+	return utilization
+}
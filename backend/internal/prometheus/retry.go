@@ -0,0 +1,112 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetryOptions configures retries of requests that failed with a transient error.
+type RetryOptions struct {
+	// MaxAttempts is the number of attempts per request including the first. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff ceiling before the first retry. It doubles with every retry up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff ceiling. Zero leaves it uncapped.
+	MaxDelay time.Duration
+}
+
+// WithRetry retries queries failing with network errors, 429 or 5xx responses, waiting a random
+// delay up to an exponentially growing ceiling between attempts. Queries are read-only, so retrying is safe.
+func WithRetry(opts RetryOptions) Option {
+	return func(c *Client) {
+		c.retry = opts
+	}
+}
+
+// statusError is a non-200 response from the Prometheus API.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("prometheus API error: status %d, body: %s", e.code, e.body)
+}
+
+// transportError is a request that got no response from Prometheus.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return fmt.Sprintf("executing request: %v", e.err)
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// upstreamFailure reports whether err indicates Prometheus is unavailable or overloaded, as opposed to
+// a rejected query or the caller giving up.
+func upstreamFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests || status.code >= http.StatusInternalServerError
+	}
+	var transport *transportError
+	return errors.As(err, &transport)
+}
+
+// backoff returns the delay before retry number attempt, counting from 1.
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	ceiling := o.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || (o.MaxDelay > 0 && ceiling > o.MaxDelay) {
+		ceiling = o.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	// Full jitter spreads out retries of queries that failed together
+	return rand.N(ceiling)
+}
+
+// getWithRetry performs the request, retrying transient failures while attempts and the context deadline allow.
+func (c *Client) getWithRetry(ctx context.Context, path, query string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		body, err := c.do(ctx, path, query)
+		if attempt >= c.retry.MaxAttempts || !upstreamFailure(ctx, err) {
+			return body, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// Waiting would outlive the caller
+			return nil, err
+		}
+
+		slog.DebugContext(ctx, "Retrying Prometheus request", "path", path, "attempt", attempt, "delay", delay, "error", err)
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
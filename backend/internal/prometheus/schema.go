@@ -0,0 +1,338 @@
+package prometheus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field names used as keys in Schema.Metrics and Schema.Processes.
+// They match the JSON names of the models.GPUMetrics and models.GPUProcess fields they populate.
+const (
+	FieldGPUMemoryFree     = "memory_free"
+	FieldGPUMemoryUsed     = "gpu_memory_used"
+	FieldGPUMemoryTotal    = "gpu_memory_total"
+	FieldGPUUtilization    = "gpu_utilization"
+	FieldGPUTemperature    = "temperature"
+	FieldCPUUtilization    = "cpu_utilization"
+	FieldMemoryUtilization = "memory_utilization"
+	FieldProcessGPUMemory  = "gpu_memory"
+)
+
+// metricFields lists the GPUMetrics fields a schema may map.
+var metricFields = []string{
+	FieldGPUMemoryFree,
+	FieldGPUMemoryUsed,
+	FieldGPUMemoryTotal,
+	FieldGPUUtilization,
+	FieldGPUTemperature,
+	FieldCPUUtilization,
+	FieldMemoryUtilization,
+}
+
+// processFields lists the GPUProcess fields a schema may map.
+var processFields = []string{
+	FieldProcessGPUMemory,
+}
+
+// nodeFields are metrics reported per node rather than per GPU.
+var nodeFields = map[string]bool{
+	FieldCPUUtilization:    true,
+	FieldMemoryUtilization: true,
+}
+
+// Schema describes the metric names and labels published by a GPU exporter.
+// Memory values must resolve to bytes; fields that are not mapped are not queried.
+type Schema struct {
+	Name string `json:"name" yaml:"name"`
+	// Base names a built-in schema this one starts from; mappings given here override it.
+	Base      string           `json:"base,omitempty" yaml:"base,omitempty"`
+	Labels    Labels           `json:"labels" yaml:"labels"`
+	Metrics   map[string]Field `json:"metrics" yaml:"metrics"`
+	Processes map[string]Field `json:"processes" yaml:"processes"`
+}
+
+// Field maps a single model field to a PromQL expression.
+type Field struct {
+	Query string `json:"query" yaml:"query"`
+	// Labels overrides the schema-wide label names for the series of this query.
+	Labels Labels `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// Labels names the series labels the client reads identifiers from.
+type Labels struct {
+	Node        string `json:"node,omitempty" yaml:"node,omitempty"`
+	GPU         string `json:"gpu,omitempty" yaml:"gpu,omitempty"`
+	GPUName     string `json:"gpu_name,omitempty" yaml:"gpu_name,omitempty"`
+	UUID        string `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	PID         string `json:"pid,omitempty" yaml:"pid,omitempty"`
+	User        string `json:"user,omitempty" yaml:"user,omitempty"`
+	Command     string `json:"command,omitempty" yaml:"command,omitempty"`
+	ProcessName string `json:"process_name,omitempty" yaml:"process_name,omitempty"`
+	// ContainerID, Namespace, Pod and Container are read from process series when the exporter attaches them.
+	ContainerID string `json:"container_id,omitempty" yaml:"container_id,omitempty"`
+	Namespace   string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Pod         string `json:"pod,omitempty" yaml:"pod,omitempty"`
+	Container   string `json:"container,omitempty" yaml:"container,omitempty"`
+}
+
+// SchemaCustom reads the series published by the project's own gpu-metrics exporter.
+var SchemaCustom = Schema{
+	Name: "custom",
+	Labels: Labels{
+		Node:        "hostname",
+		GPU:         "gpu_id",
+		GPUName:     "gpu_name",
+		PID:         "pid",
+		User:        "user",
+		Command:     "command",
+		ProcessName: "process_name",
+		ContainerID: "container_id",
+		Namespace:   "namespace",
+		Pod:         "pod",
+		Container:   "container",
+	},
+	Metrics: map[string]Field{
+		FieldGPUMemoryFree:     {Query: `gpu_metrics_free_memory`},
+		FieldGPUMemoryUsed:     {Query: `gpu_metrics_used_memory`},
+		FieldGPUMemoryTotal:    {Query: `gpu_metrics_total_memory`},
+		FieldGPUUtilization:    {Query: `gpu_metrics_utilization_percent`},
+		FieldGPUTemperature:    {Query: `gpu_metrics_temperature`},
+		FieldCPUUtilization:    {Query: `gpu_metrics_cpu_utilization`},
+		FieldMemoryUtilization: {Query: `gpu_metrics_memory_utilization`},
+	},
+	Processes: map[string]Field{
+		FieldProcessGPUMemory: {Query: `gpu_process_gpu_memory`},
+	},
+}
+
+// SchemaDCGM reads the series published by the stock NVIDIA dcgm-exporter.
+// DCGM reports framebuffer memory in MiB, so it is converted to bytes. The exporter
+// publishes neither host CPU/memory utilization nor per-process series.
+var SchemaDCGM = Schema{
+	Name: "dcgm",
+	Labels: Labels{
+		Node:    "Hostname",
+		GPU:     "gpu",
+		GPUName: "modelName",
+		UUID:    "UUID",
+	},
+	Metrics: map[string]Field{
+		FieldGPUMemoryFree:  {Query: `DCGM_FI_DEV_FB_FREE * 1048576`},
+		FieldGPUMemoryUsed:  {Query: `DCGM_FI_DEV_FB_USED * 1048576`},
+		FieldGPUMemoryTotal: {Query: `(DCGM_FI_DEV_FB_FREE + DCGM_FI_DEV_FB_USED) * 1048576`},
+		FieldGPUUtilization: {Query: `DCGM_FI_DEV_GPU_UTIL`},
+		FieldGPUTemperature: {Query: `DCGM_FI_DEV_GPU_TEMP`},
+	},
+	Processes: map[string]Field{},
+}
+
+// LookupSchema returns the built-in schema with the given name.
+func LookupSchema(name string) (Schema, error) {
+	switch name {
+	case SchemaCustom.Name:
+		return SchemaCustom, nil
+	case SchemaDCGM.Name:
+		return SchemaDCGM, nil
+	default:
+		return Schema{}, fmt.Errorf("unknown metric schema %q", name)
+	}
+}
+
+// LoadSchema reads a schema mapping file. Files ending in .json are parsed as JSON, anything else as YAML.
+// Unknown keys are rejected and the result is validated before it is returned.
+func LoadSchema(path string) (Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Schema{}, fmt.Errorf("reading metric schema: %w", err)
+	}
+
+	var schema Schema
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&schema)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&schema)
+	}
+	if err != nil {
+		return Schema{}, fmt.Errorf("parsing metric schema %s: %w", path, err)
+	}
+
+	if schema.Base != "" {
+		base, err := LookupSchema(schema.Base)
+		if err != nil {
+			return Schema{}, fmt.Errorf("metric schema %s: %w", path, err)
+		}
+		schema = schema.over(base)
+	}
+
+	if err := schema.Validate(); err != nil {
+		return Schema{}, fmt.Errorf("invalid metric schema %s: %w", path, err)
+	}
+
+	return schema, nil
+}
+
+// over returns s layered on top of base: labels and field mappings set in s take precedence.
+func (s Schema) over(base Schema) Schema {
+	merged := Schema{
+		Name:      s.Name,
+		Labels:    s.Labels.over(base.Labels),
+		Metrics:   make(map[string]Field),
+		Processes: make(map[string]Field),
+	}
+	if merged.Name == "" {
+		merged.Name = base.Name
+	}
+
+	for name, field := range base.Metrics {
+		merged.Metrics[name] = field
+	}
+	for name, field := range s.Metrics {
+		merged.Metrics[name] = field
+	}
+	for name, field := range base.Processes {
+		merged.Processes[name] = field
+	}
+	for name, field := range s.Processes {
+		merged.Processes[name] = field
+	}
+
+	return merged
+}
+
+// Validate reports every problem in the schema: unknown fields, empty queries,
+// unbalanced expressions and missing identifying labels.
+func (s Schema) Validate() error {
+	var errs []error
+
+	errs = append(errs, validateFields("metrics", s.Metrics, metricFields)...)
+	errs = append(errs, validateFields("processes", s.Processes, processFields)...)
+
+	for _, name := range slices.Sorted(maps.Keys(s.Metrics)) {
+		labels := s.labels(s.Metrics[name])
+		if labels.Node == "" {
+			errs = append(errs, fmt.Errorf("metrics.%s: node label is not set", name))
+		}
+		if labels.GPU == "" && !nodeFields[name] {
+			errs = append(errs, fmt.Errorf("metrics.%s: gpu label is not set", name))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Processes)) {
+		labels := s.labels(s.Processes[name])
+		if labels.Node == "" || labels.GPU == "" || labels.PID == "" {
+			errs = append(errs, fmt.Errorf("processes.%s: node, gpu and pid labels must be set", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateFields checks that every mapping targets a known field and has a plausible query.
+func validateFields(section string, fields map[string]Field, known []string) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if !slices.Contains(known, name) {
+			errs = append(errs, fmt.Errorf("%s.%s: unknown field (expected one of %s)", section, name, strings.Join(known, ", ")))
+			continue
+		}
+		query := strings.TrimSpace(fields[name].Query)
+		if query == "" {
+			errs = append(errs, fmt.Errorf("%s.%s: query is empty", section, name))
+			continue
+		}
+		if err := checkBalanced(query); err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", section, name, err))
+		}
+	}
+	return errs
+}
+
+// checkBalanced performs a cheap syntax check that brackets and quotes in a PromQL expression are balanced.
+func checkBalanced(query string) error {
+	pairs := map[rune]rune{')': '(', '}': '{', ']': '['}
+	var stack []rune
+	var quote rune
+
+	for i, r := range query {
+		if quote != 0 {
+			if r == quote && (i == 0 || query[i-1] != '\\') {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '"', '\'', '`':
+			quote = r
+		case '(', '{', '[':
+			stack = append(stack, r)
+		case ')', '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[r] {
+				return fmt.Errorf("unbalanced %q in query %q", r, query)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if quote != 0 {
+		return fmt.Errorf("unterminated string in query %q", query)
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q in query %q", stack[len(stack)-1], query)
+	}
+	return nil
+}
+
+// labels returns the label names used for the series of field.
+func (s Schema) labels(field Field) Labels {
+	return field.Labels.over(s.Labels)
+}
+
+// over returns l with empty names filled in from base.
+func (l Labels) over(base Labels) Labels {
+	pick := func(v, fallback string) string {
+		if v != "" {
+			return v
+		}
+		return fallback
+	}
+	return Labels{
+		Node:        pick(l.Node, base.Node),
+		GPU:         pick(l.GPU, base.GPU),
+		GPUName:     pick(l.GPUName, base.GPUName),
+		UUID:        pick(l.UUID, base.UUID),
+		PID:         pick(l.PID, base.PID),
+		User:        pick(l.User, base.User),
+		Command:     pick(l.Command, base.Command),
+		ProcessName: pick(l.ProcessName, base.ProcessName),
+		ContainerID: pick(l.ContainerID, base.ContainerID),
+		Namespace:   pick(l.Namespace, base.Namespace),
+		Pod:         pick(l.Pod, base.Pod),
+		Container:   pick(l.Container, base.Container),
+	}
+}
+
+// metricNamePattern matches a bare metric name that label matchers can be appended to.
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// withMatchers appends label matchers such as `hostname="node1"` to query when it is a bare metric name.
+// Other expressions are returned unchanged and must be filtered after querying.
+func withMatchers(query string, matchers []string) string {
+	if len(matchers) == 0 || !metricNamePattern.MatchString(query) {
+		return query
+	}
+	return query + "{" + strings.Join(matchers, ",") + "}"
+}
@@ -0,0 +1,302 @@
+package prometheus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TenantHeader is the header Mimir, Cortex and Loki gateways read the tenant from.
+const TenantHeader = "X-Scope-OrgID"
+
+// TransportConfig configures how the client connects and authenticates to Prometheus.
+// Credentials and client certificates are read from files and reloaded when the files change.
+type TransportConfig struct {
+	// BasicAuthUsername and BasicAuthPasswordFile enable HTTP basic authentication.
+	BasicAuthUsername     string `json:"basic_auth_username,omitempty" yaml:"basic_auth_username,omitempty"`
+	BasicAuthPasswordFile string `json:"basic_auth_password_file,omitempty" yaml:"basic_auth_password_file,omitempty"`
+	// BearerTokenFile holds a token sent as "Authorization: Bearer".
+	BearerTokenFile string `json:"bearer_token_file,omitempty" yaml:"bearer_token_file,omitempty"`
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	// CertFile and KeyFile are the PEM client certificate and key presented for mTLS.
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	// InsecureSkipVerify disables verification of the server certificate.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+	// ProxyURL routes requests through an HTTP proxy. The HTTP_PROXY environment variables apply when empty.
+	ProxyURL string `json:"proxy_url,omitempty" yaml:"proxy_url,omitempty"`
+	// TenantID is sent as the X-Scope-OrgID header.
+	TenantID string `json:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	// Headers are added to every request.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// WithDefaults returns c with every unset field taken from defaults. Headers are merged, c winning.
+func (c TransportConfig) WithDefaults(defaults TransportConfig) TransportConfig {
+	// Credentials are taken as a whole so a cluster's bearer token is not combined with a default basic auth
+	if c.BasicAuthUsername == "" && c.BasicAuthPasswordFile == "" && c.BearerTokenFile == "" {
+		c.BasicAuthUsername = defaults.BasicAuthUsername
+		c.BasicAuthPasswordFile = defaults.BasicAuthPasswordFile
+		c.BearerTokenFile = defaults.BearerTokenFile
+	}
+	if c.CertFile == "" && c.KeyFile == "" {
+		c.CertFile = defaults.CertFile
+		c.KeyFile = defaults.KeyFile
+	}
+	if c.CAFile == "" {
+		c.CAFile = defaults.CAFile
+	}
+	if c.ProxyURL == "" {
+		c.ProxyURL = defaults.ProxyURL
+	}
+	if c.TenantID == "" {
+		c.TenantID = defaults.TenantID
+	}
+	c.InsecureSkipVerify = c.InsecureSkipVerify || defaults.InsecureSkipVerify
+
+	headers := maps.Clone(defaults.Headers)
+	if headers == nil {
+		headers = c.Headers
+	} else {
+		maps.Copy(headers, c.Headers)
+	}
+	c.Headers = headers
+	return c
+}
+
+// Validate reports every problem in the configuration at once.
+func (c TransportConfig) Validate() error {
+	var errs []error
+	if c.BearerTokenFile != "" && (c.BasicAuthUsername != "" || c.BasicAuthPasswordFile != "") {
+		errs = append(errs, errors.New("bearer_token_file and basic auth are mutually exclusive"))
+	}
+	if (c.BasicAuthUsername == "") != (c.BasicAuthPasswordFile == "") {
+		errs = append(errs, errors.New("basic auth requires both basic_auth_username and basic_auth_password_file"))
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, errors.New("client certificates require both cert_file and key_file"))
+	}
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid proxy_url %q", c.ProxyURL))
+		}
+	}
+	for name := range c.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			errs = append(errs, fmt.Errorf("invalid header name %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewTransport builds an HTTP transport applying cfg. The CA bundle is read once; credentials
+// and client certificates are read on first use and whenever their files change.
+func NewTransport(cfg TransportConfig) (http.RoundTripper, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s contains no PEM certificates", cfg.CAFile)
+		}
+		base.TLSClientConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert := &clientCertificate{certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+		// Fail at startup rather than on the first query
+		if _, err := cert.get(nil); err != nil {
+			return nil, err
+		}
+		base.TLSClientConfig.GetClientCertificate = cert.get
+	}
+
+	if cfg.ProxyURL != "" {
+		proxy, _ := url.Parse(cfg.ProxyURL)
+		base.Proxy = http.ProxyURL(proxy)
+	}
+
+	t := &transport{
+		next:     base,
+		headers:  make(http.Header),
+		username: cfg.BasicAuthUsername,
+	}
+	for name, value := range cfg.Headers {
+		t.headers.Set(name, value)
+	}
+	if cfg.TenantID != "" {
+		t.headers.Set(TenantHeader, cfg.TenantID)
+	}
+	if cfg.BasicAuthPasswordFile != "" {
+		t.password = &secretFile{path: cfg.BasicAuthPasswordFile}
+	}
+	if cfg.BearerTokenFile != "" {
+		t.token = &secretFile{path: cfg.BearerTokenFile}
+	}
+
+	return t, nil
+}
+
+// WithTransport sends requests through rt, typically built by NewTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
+
+// transport adds headers and credentials to requests before passing them to next.
+type transport struct {
+	next     http.RoundTripper
+	headers  http.Header
+	username string
+	password *secretFile
+	token    *secretFile
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+
+	if t.token != nil {
+		token, err := t.token.read()
+		if err != nil {
+			return nil, &credentialError{err: err}
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if t.password != nil {
+		password, err := t.password.read()
+		if err != nil {
+			return nil, &credentialError{err: err}
+		}
+		req.SetBasicAuth(t.username, password)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// credentialError is a credential file that could not be read. The request is never sent, so it is
+// a configuration problem rather than an unavailable Prometheus.
+type credentialError struct {
+	err error
+}
+
+func (e *credentialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *credentialError) Unwrap() error {
+	return e.err
+}
+
+// fileVersion identifies the content of a file by its modification time and size.
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the current version of the file at path.
+func statFile(path string) (fileVersion, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// secretFile caches a credential read from a file, reading it again when the file changes.
+type secretFile struct {
+	path string
+
+	mu      sync.Mutex
+	version fileVersion
+	value   string
+}
+
+// read returns the trimmed content of the file.
+func (f *secretFile) read() (string, error) {
+	version, err := statFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("reading credentials: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if version != f.version {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return "", fmt.Errorf("reading credentials: %w", err)
+		}
+		f.value = strings.TrimSpace(string(data))
+		f.version = version
+	}
+	return f.value, nil
+}
+
+// clientCertificate caches a client certificate, loading it again when either file changes.
+type clientCertificate struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certVersion fileVersion
+	keyVersion  fileVersion
+	cert        *tls.Certificate
+}
+
+// get returns the current certificate. It has the signature of tls.Config.GetClientCertificate.
+func (c *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	certVersion, err := statFile(c.certFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client certificate: %w", err)
+	}
+	keyVersion, err := statFile(c.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client key: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cert == nil || certVersion != c.certVersion || keyVersion != c.keyVersion {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			if c.cert != nil {
+				// The pair may be mid-rotation; keep the previous certificate and retry on the next handshake
+				return c.cert, nil
+			}
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		c.cert = &cert
+		c.certVersion, c.keyVersion = certVersion, keyVersion
+	}
+	return c.cert, nil
+}
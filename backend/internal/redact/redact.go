@@ -0,0 +1,287 @@
+// Package redact hides process owners and command lines from callers that may not see them.
+package redact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// Mode decides how a field is presented.
+type Mode string
+
+const (
+	// ModeShow returns the value unchanged, apart from scrubbing command lines.
+	ModeShow Mode = "show"
+	// ModeMask replaces the value with Masked.
+	ModeMask Mode = "mask"
+	// ModeHash replaces the value with a short hash so equal values stay recognisable.
+	ModeHash Mode = "hash"
+)
+
+// Masked is the value shown in place of masked fields.
+const Masked = "***"
+
+// defaultReplacement keeps the first capture group, typically the flag name, and masks the rest of the match.
+const defaultReplacement = "${1}" + Masked
+
+// DefaultScrubbers are applied to shown command lines when the policy does not list its own.
+var DefaultScrubbers = []Scrubber{
+	{Pattern: `(?i)(--?[a-z0-9_-]*(?:password|passwd|secret|token|api[_-]?key)[a-z0-9_-]*[= ])\S+`},
+	{Pattern: `(?i)(\b[a-z0-9_]*(?:password|passwd|secret|token|api_?key)[a-z0-9_]*=)\S+`},
+	{Pattern: `(://[^/:@\s]+:)[^@\s]+(@)`, Replacement: "${1}" + Masked + "${2}"},
+}
+
+// Fields sets the mode of each redactable process field. Empty modes are inherited.
+type Fields struct {
+	User        Mode `json:"user,omitempty" yaml:"user,omitempty"`
+	Command     Mode `json:"command,omitempty" yaml:"command,omitempty"`
+	ProcessName Mode `json:"process_name,omitempty" yaml:"process_name,omitempty"`
+}
+
+// Rule applies its field modes to callers belonging to any of Groups.
+type Rule struct {
+	Groups []string `json:"groups" yaml:"groups"`
+	Fields `yaml:",inline"`
+}
+
+// Scrubber replaces matches of Pattern in command lines with Replacement.
+type Scrubber struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Replacement may refer to capture groups; defaults to "${1}***".
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
+
+// Config is the redaction policy file.
+type Config struct {
+	// Default applies to callers matching no rule, including unauthenticated ones. Unset fields are shown.
+	Default Fields `json:"default" yaml:"default"`
+	// Rules are tried in order and the first matching the caller's groups wins.
+	Rules []Rule `json:"rules" yaml:"rules"`
+	// Scrubbers replace DefaultScrubbers when set.
+	Scrubbers []Scrubber `json:"scrubbers" yaml:"scrubbers"`
+	// HashSalt is mixed into hashed values so they cannot be looked up by hashing known names.
+	HashSalt string `json:"hash_salt,omitempty" yaml:"hash_salt,omitempty"`
+}
+
+// Policy redacts process fields according to the caller's groups.
+// A nil Policy returns everything unchanged.
+type Policy struct {
+	defaults  Fields
+	rules     []Rule
+	scrubbers []compiledScrubber
+	salt      string
+}
+
+type compiledScrubber struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// LoadPolicy reads a redaction policy from a YAML or JSON file, chosen by extension.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading redaction policy: %w", err)
+	}
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing redaction policy %s: %w", path, err)
+	}
+
+	policy, err := NewPolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// NewPolicy validates the configuration and compiles its scrubbers.
+func NewPolicy(cfg Config) (*Policy, error) {
+	var errs []error
+	checkFields := func(where string, f Fields) {
+		for _, field := range []struct {
+			name string
+			mode Mode
+		}{{"user", f.User}, {"command", f.Command}, {"process_name", f.ProcessName}} {
+			if field.mode != "" && field.mode != ModeShow && field.mode != ModeMask && field.mode != ModeHash {
+				errs = append(errs, fmt.Errorf("%s.%s: unknown mode %q", where, field.name, field.mode))
+			}
+		}
+	}
+
+	checkFields("default", cfg.Default)
+	for i, rule := range cfg.Rules {
+		if len(rule.Groups) == 0 {
+			errs = append(errs, fmt.Errorf("rules[%d]: at least one group is required", i))
+		}
+		checkFields(fmt.Sprintf("rules[%d]", i), rule.Fields)
+	}
+
+	scrubbers := cfg.Scrubbers
+	if scrubbers == nil {
+		scrubbers = DefaultScrubbers
+	}
+	compiled := make([]compiledScrubber, 0, len(scrubbers))
+	for i, s := range scrubbers {
+		if s.Pattern == "" {
+			errs = append(errs, fmt.Errorf("scrubbers[%d]: pattern is not set", i))
+			continue
+		}
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scrubbers[%d]: %w", i, err))
+			continue
+		}
+		replacement := s.Replacement
+		if replacement == "" {
+			replacement = defaultReplacement
+		}
+		compiled = append(compiled, compiledScrubber{pattern: re, replacement: replacement})
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	defaults := cfg.Default
+	defaults.User = orShow(defaults.User)
+	defaults.Command = orShow(defaults.Command)
+	defaults.ProcessName = orShow(defaults.ProcessName)
+
+	return &Policy{
+		defaults:  defaults,
+		rules:     cfg.Rules,
+		scrubbers: compiled,
+		salt:      cfg.HashSalt,
+	}, nil
+}
+
+// fieldsFor resolves the field modes for a caller in groups.
+func (p *Policy) fieldsFor(groups []string) Fields {
+	for _, rule := range p.rules {
+		if !slices.ContainsFunc(rule.Groups, func(g string) bool { return slices.Contains(groups, g) }) {
+			continue
+		}
+		return Fields{
+			User:        inherit(rule.User, p.defaults.User),
+			Command:     inherit(rule.Command, p.defaults.Command),
+			ProcessName: inherit(rule.ProcessName, p.defaults.ProcessName),
+		}
+	}
+	return p.defaults
+}
+
+// Processes returns a redacted copy of procs for a caller in groups.
+func (p *Policy) Processes(groups []string, procs []models.GPUProcess) []models.GPUProcess {
+	if p == nil || procs == nil {
+		return procs
+	}
+
+	fields := p.fieldsFor(groups)
+	redacted := make([]models.GPUProcess, len(procs))
+	for i, proc := range procs {
+		proc.User = p.apply(fields.User, proc.User)
+		proc.ProcessName = p.apply(fields.ProcessName, proc.ProcessName)
+		if fields.Command == ModeShow {
+			proc.Command = p.Scrub(proc.Command)
+		} else {
+			proc.Command = p.apply(fields.Command, proc.Command)
+		}
+		redacted[i] = proc
+	}
+	return redacted
+}
+
+// IdleGPUs returns a copy of idle GPUs whose processes are redacted for a caller in groups.
+func (p *Policy) IdleGPUs(groups []string, gpus []models.IdleGPU) []models.IdleGPU {
+	if p == nil || gpus == nil {
+		return gpus
+	}
+
+	redacted := make([]models.IdleGPU, len(gpus))
+	for i, gpu := range gpus {
+		gpu.Processes = p.Processes(groups, gpu.Processes)
+		redacted[i] = gpu
+	}
+	return redacted
+}
+
+// Users returns a copy of per-user usage whose names are redacted for a caller in groups.
+func (p *Policy) Users(groups []string, usages []models.GPUUsage) []models.GPUUsage {
+	if p == nil || usages == nil {
+		return usages
+	}
+
+	mode := p.fieldsFor(groups).User
+	redacted := make([]models.GPUUsage, len(usages))
+	for i, u := range usages {
+		if !u.Unattributed {
+			u.Name = p.apply(mode, u.Name)
+		}
+		redacted[i] = u
+	}
+	return redacted
+}
+
+// Scrub replaces secrets in a command line using the policy's scrubbers.
+func (p *Policy) Scrub(command string) string {
+	if p == nil {
+		return command
+	}
+	for _, s := range p.scrubbers {
+		command = s.pattern.ReplaceAllString(command, s.replacement)
+	}
+	return command
+}
+
+// apply presents a non-empty value according to mode.
+func (p *Policy) apply(mode Mode, value string) string {
+	if value == "" {
+		return value
+	}
+	switch mode {
+	case ModeMask:
+		return Masked
+	case ModeHash:
+		sum := sha256.Sum256([]byte(p.salt + value))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	default:
+		return value
+	}
+}
+
+// inherit returns mode, or fallback when mode is unset.
+func inherit(mode, fallback Mode) Mode {
+	if mode == "" {
+		return fallback
+	}
+	return mode
+}
+
+// orShow defaults an unset mode to ModeShow.
+func orShow(mode Mode) Mode {
+	return inherit(mode, ModeShow)
+}
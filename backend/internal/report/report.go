@@ -0,0 +1,171 @@
+// Package report carries details about how a result was produced (for example,
+// that it was served from a stale cache) from the data layer back to the HTTP
+// handlers through the request context.
+package report
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// Report collects response annotations for a single request. A nil *Report is valid and discards everything.
+type Report struct {
+	mu        sync.Mutex
+	stale     bool
+	fetchedAt time.Time
+	warnings  []models.Warning
+	clusters  []models.ClusterStatus
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying a new, empty Report.
+func NewContext(ctx context.Context) (context.Context, *Report) {
+	r := &Report{}
+	return context.WithValue(ctx, contextKey{}, r), r
+}
+
+// FromContext returns the Report carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Report {
+	r, _ := ctx.Value(contextKey{}).(*Report)
+	return r
+}
+
+// MarkStale records that the result is a cached snapshot fetched at fetchedAt that could not be refreshed.
+func (r *Report) MarkStale(fetchedAt time.Time) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Keep the oldest snapshot time when several stale results are combined
+	if !r.stale || fetchedAt.Before(r.fetchedAt) {
+		r.fetchedAt = fetchedAt
+	}
+	r.stale = true
+}
+
+// Stale reports whether the result was stale and when the stale snapshot was fetched.
+func (r *Report) Stale() (bool, time.Time) {
+	if r == nil {
+		return false, time.Time{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stale, r.fetchedAt
+}
+
+// AddWarning records that query failed and its data is missing from the result.
+// Only the first failure of each query is kept.
+func (r *Report) AddWarning(query string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addWarning(models.Warning{Query: query, Message: err.Error()})
+}
+
+// addWarning appends w unless its query already has a warning. r.mu must be held.
+func (r *Report) addWarning(w models.Warning) {
+	for _, existing := range r.warnings {
+		if existing.Cluster == w.Cluster && existing.Query == w.Query {
+			return
+		}
+	}
+	r.warnings = append(r.warnings, w)
+}
+
+// Warnings returns a copy of the warnings recorded so far, or nil if there are none.
+func (r *Report) Warnings() []models.Warning {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.warnings) == 0 {
+		return nil
+	}
+	return append([]models.Warning(nil), r.warnings...)
+}
+
+// SetClusterStatus records whether the federated cluster name could be read; err is nil on success.
+// A later status for the same cluster replaces the earlier one.
+func (r *Report) SetClusterStatus(name string, err error) {
+	if r == nil {
+		return
+	}
+	status := models.ClusterStatus{Name: name, Status: models.ClusterStatusOK}
+	if err != nil {
+		status.Status = models.ClusterStatusError
+		status.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setClusterStatus(status)
+}
+
+// setClusterStatus adds or replaces the status of a cluster. r.mu must be held.
+func (r *Report) setClusterStatus(status models.ClusterStatus) {
+	for i, existing := range r.clusters {
+		if existing.Name == status.Name {
+			r.clusters[i] = status
+			return
+		}
+	}
+	r.clusters = append(r.clusters, status)
+}
+
+// Clusters returns a copy of the cluster statuses recorded so far, or nil if there are none.
+func (r *Report) Clusters() []models.ClusterStatus {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.clusters) == 0 {
+		return nil
+	}
+	return append([]models.ClusterStatus(nil), r.clusters...)
+}
+
+// Merge copies the annotations of other into r, for results produced under a different report.
+func (r *Report) Merge(other *Report) {
+	r.merge(other, "")
+}
+
+// MergeCluster copies the annotations of other, produced while reading the federated cluster name, into r.
+// Warnings without a cluster are attributed to name.
+func (r *Report) MergeCluster(name string, other *Report) {
+	r.merge(other, name)
+}
+
+// merge copies the annotations of other into r, attributing unattributed warnings to cluster.
+func (r *Report) merge(other *Report, cluster string) {
+	if r == nil || other == nil || r == other {
+		return
+	}
+	stale, fetchedAt := other.Stale()
+	warnings := other.Warnings()
+	clusters := other.Clusters()
+
+	if stale {
+		r.MarkStale(fetchedAt)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range warnings {
+		if w.Cluster == "" {
+			w.Cluster = cluster
+		}
+		r.addWarning(w)
+	}
+	for _, status := range clusters {
+		r.setClusterStatus(status)
+	}
+}
@@ -0,0 +1,135 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// Fixture is an in-memory MetricsSource serving a fixed data set.
+// It is used for tests and for running the API without a Prometheus server.
+type Fixture struct {
+	Metrics   []models.GPUMetrics `json:"metrics"`
+	Processes []models.GPUProcess `json:"processes"`
+	// Err, when set, is returned by every method instead of data.
+	Err error `json:"-"`
+}
+
+var (
+	_ MetricsSource       = (*Fixture)(nil)
+	_ HistorySource       = (*Fixture)(nil)
+	_ ProcessWindowSource = (*Fixture)(nil)
+	_ UtilizationSource   = (*Fixture)(nil)
+)
+
+// NewFixture creates a new fixture source serving the given metrics and processes.
+func NewFixture(metrics []models.GPUMetrics, processes []models.GPUProcess) *Fixture {
+	return &Fixture{
+		Metrics:   metrics,
+		Processes: processes,
+	}
+}
+
+// LoadFixture reads a fixture from a JSON file with "metrics" and "processes" arrays.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture: %w", err)
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", path, err)
+	}
+
+	return &f, nil
+}
+
+// GetGPUMetrics returns the fixture metrics.
+func (f *Fixture) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if f.Metrics == nil {
+		return []models.GPUMetrics{}, nil
+	}
+	return f.Metrics, nil
+}
+
+// GetGPUProcesses returns the fixture processes.
+func (f *Fixture) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if f.Processes == nil {
+		return []models.GPUProcess{}, nil
+	}
+	return f.Processes, nil
+}
+
+// GetGPUUtilization returns the utilization of the fixture GPUs.
+func (f *Fixture) GetGPUUtilization(ctx context.Context) ([]models.GPUUtilization, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	utilization := make([]models.GPUUtilization, 0, len(f.Metrics))
+	for _, m := range f.Metrics {
+		utilization = append(utilization, models.GPUUtilization{
+			NodeName:       m.NodeName,
+			GPUIndex:       m.GPUIndex,
+			GPUName:        m.GPUName,
+			GPUUtilization: m.GPUUtilization,
+			Timestamp:      m.Timestamp,
+		})
+	}
+	return utilization, nil
+}
+
+// GetGPUProcessesOverWindow returns the fixture processes; a fixture has no history to look back on.
+func (f *Fixture) GetGPUProcessesOverWindow(ctx context.Context, window time.Duration) ([]models.GPUProcess, error) {
+	return f.GetGPUProcesses(ctx)
+}
+
+// Ping returns the fixture error, if any.
+func (f *Fixture) Ping(ctx context.Context) error {
+	return f.Err
+}
+
+// GetGPUMetricsHistory returns a single-sample series per fixture GPU matching the node and GPU filters of q.
+func (f *Fixture) GetGPUMetricsHistory(ctx context.Context, q models.MetricsQuery) ([]models.GPUMetricsSeries, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	history := []models.GPUMetricsSeries{}
+	for _, m := range f.Metrics {
+		if q.Node != "" && m.NodeName != q.Node {
+			continue
+		}
+		if q.GPU != "" && strconv.Itoa(m.GPUIndex) != q.GPU {
+			continue
+		}
+
+		history = append(history, models.GPUMetricsSeries{
+			NodeName: m.NodeName,
+			GPUIndex: m.GPUIndex,
+			GPUName:  m.GPUName,
+			Samples: []models.GPUMetricsSample{
+				{
+					Timestamp:      m.Timestamp,
+					GPUUtilization: m.GPUUtilization,
+					GPUMemoryUsed:  m.GPUMemoryUsed,
+					GPUTemperature: m.GPUTemperature,
+				},
+			},
+		})
+	}
+
+	return history, nil
+}
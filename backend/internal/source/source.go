@@ -0,0 +1,74 @@
+// Package source defines the interface between the API handlers and the
+// backends GPU data is read from.
+package source
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// ErrUnsupported is returned when a source cannot serve the requested kind of data.
+var ErrUnsupported = errors.New("operation not supported by metrics source")
+
+// MetricsSource provides current GPU metrics and processes.
+type MetricsSource interface {
+	// GetGPUMetrics returns the current metrics of every GPU.
+	GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error)
+	// GetGPUProcesses returns the processes currently running on GPUs.
+	GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error)
+	// Ping verifies that the backend is reachable.
+	Ping(ctx context.Context) error
+}
+
+// HistorySource is implemented by sources that can serve GPU metrics over a time range.
+type HistorySource interface {
+	GetGPUMetricsHistory(ctx context.Context, q models.MetricsQuery) ([]models.GPUMetricsSeries, error)
+}
+
+// UtilizationSource is implemented by sources that can read GPU utilization alone, more cheaply than full metrics.
+type UtilizationSource interface {
+	GetGPUUtilization(ctx context.Context) ([]models.GPUUtilization, error)
+}
+
+// ProcessWindowSource is implemented by sources that can report GPU processes seen over a lookback window.
+type ProcessWindowSource interface {
+	// GetGPUProcessesOverWindow returns every process seen within window with its peak GPU memory.
+	GetGPUProcessesOverWindow(ctx context.Context, window time.Duration) ([]models.GPUProcess, error)
+}
+
+// ClusterSource is implemented by sources that federate several named clusters.
+type ClusterSource interface {
+	// Clusters returns the names of the federated clusters.
+	Clusters() []string
+}
+
+// CircuitSource is implemented by sources that stop calling a failing upstream for a while.
+type CircuitSource interface {
+	// Circuits returns the state of each upstream circuit breaker.
+	Circuits() []models.CircuitStatus
+}
+
+// Wrapper is implemented by sources that decorate another source, such as caches.
+type Wrapper interface {
+	Unwrap() MetricsSource
+}
+
+// As walks the chain of wrapped sources starting at src and returns the first one implementing T.
+func As[T any](src MetricsSource) (T, bool) {
+	for src != nil {
+		if t, ok := src.(T); ok {
+			return t, true
+		}
+		w, ok := src.(Wrapper)
+		if !ok {
+			break
+		}
+		src = w.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
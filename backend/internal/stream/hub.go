@@ -0,0 +1,194 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+)
+
+// FetchFunc retrieves the current GPU metrics for a snapshot.
+type FetchFunc func(ctx context.Context) ([]models.GPUMetrics, error)
+
+// Snapshot represents the result of a single poll shared with every subscriber.
+type Snapshot struct {
+	Sequence  uint64
+	Metrics   []models.GPUMetrics
+	Err       error
+	Report    *report.Report
+	FetchedAt time.Time
+}
+
+// Hub polls GPU metrics on a single goroutine and fans snapshots out to subscribers.
+// Polling only happens while at least one subscriber is connected.
+type Hub struct {
+	fetch    FetchFunc
+	interval time.Duration
+	timeout  time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	latest      *Snapshot
+	closed      bool
+	sequence    uint64
+
+	wake chan struct{}
+}
+
+// Subscription receives snapshots from a Hub.
+// Its channel holds at most one pending snapshot; a slow reader only ever sees the latest one.
+type Subscription struct {
+	ch      chan Snapshot
+	dropped atomic.Uint64
+}
+
+// NewHub creates a new hub that polls fetch every interval.
+func NewHub(fetch FetchFunc, interval time.Duration) *Hub {
+	return &Hub{
+		fetch:       fetch,
+		interval:    interval,
+		timeout:     30 * time.Second,
+		subscribers: make(map[*Subscription]struct{}),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Run polls until ctx is cancelled, then closes all subscriptions.
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	defer h.close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-h.wake:
+		}
+
+		if h.SubscriberCount() == 0 {
+			continue
+		}
+		h.poll(ctx)
+	}
+}
+
+// poll fetches a snapshot and publishes it to all subscribers.
+func (h *Hub) poll(ctx context.Context) {
+	pollCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	pollCtx, rep := report.NewContext(pollCtx)
+
+	metrics, err := h.fetch(pollCtx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sequence++
+	snapshot := Snapshot{
+		Sequence:  h.sequence,
+		Metrics:   metrics,
+		Err:       err,
+		Report:    rep,
+		FetchedAt: time.Now(),
+	}
+	h.latest = &snapshot
+
+	for sub := range h.subscribers {
+		sub.offer(snapshot)
+	}
+}
+
+// Subscribe registers a new subscription and delivers the latest snapshot, if any, immediately.
+// It returns nil once the hub has stopped.
+func (h *Hub) Subscribe() *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+
+	sub := &Subscription{ch: make(chan Snapshot, 1)}
+	h.subscribers[sub] = struct{}{}
+
+	if h.latest != nil && time.Since(h.latest.FetchedAt) < h.interval {
+		sub.offer(*h.latest)
+	} else {
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return sub
+}
+
+// Unsubscribe removes a subscription from the hub.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// SubscriberCount returns the number of connected subscribers.
+func (h *Hub) SubscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// close closes every subscription and rejects new ones.
+func (h *Hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// C returns the channel snapshots are delivered on. It is closed when the subscription ends.
+func (s *Subscription) C() <-chan Snapshot {
+	return s.ch
+}
+
+// Dropped returns how many snapshots were discarded because the subscriber fell behind.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// offer delivers a snapshot without blocking, replacing any snapshot the reader has not consumed yet.
+// Callers must hold the hub lock.
+func (s *Subscription) offer(snapshot Snapshot) {
+	select {
+	case s.ch <- snapshot:
+		return
+	default:
+	}
+
+	select {
+	case <-s.ch:
+		s.dropped.Add(1)
+	default:
+	}
+
+	select {
+	case s.ch <- snapshot:
+	default:
+		s.dropped.Add(1)
+	}
+}
@@ -0,0 +1,90 @@
+// Package summary aggregates per-GPU metrics into per-node summaries.
+package summary
+
+import (
+	"slices"
+	"sort"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// nodeKey identifies a node within its cluster.
+type nodeKey struct {
+	cluster string
+	node    string
+}
+
+// ByNode aggregates GPU metrics per node, sorted by cluster and node name.
+// Node-level CPU and memory utilization are repeated on every GPU row and are taken once per node.
+// Values missing from a GPU are left out of its node's aggregates, which stay nil if no GPU reports them.
+func ByNode(metrics []models.GPUMetrics) []models.NodeSummary {
+	nodeMap := make(map[nodeKey]*models.NodeSummary)
+	utilizationSum := make(map[nodeKey]float64)
+	utilizationCount := make(map[nodeKey]int)
+
+	for _, m := range metrics {
+		key := nodeKey{cluster: m.Cluster, node: m.NodeName}
+		node, exists := nodeMap[key]
+		if !exists {
+			node = &models.NodeSummary{
+				Cluster:   m.Cluster,
+				NodeName:  m.NodeName,
+				GPUModels: []string{},
+				Timestamp: m.Timestamp,
+			}
+			nodeMap[key] = node
+		}
+
+		node.GPUCount++
+		node.GPUMemoryUsed = addInt(node.GPUMemoryUsed, m.GPUMemoryUsed)
+		node.GPUMemoryTotal = addInt(node.GPUMemoryTotal, m.GPUMemoryTotal)
+		node.GPUMemoryFree = addInt(node.GPUMemoryFree, m.GPUMemoryFree)
+		if m.GPUTemperature != nil && (node.MaxTemperature == nil || *m.GPUTemperature > *node.MaxTemperature) {
+			node.MaxTemperature = m.GPUTemperature
+		}
+		if node.CPUUtilization == nil {
+			node.CPUUtilization = m.CPUUtilization
+		}
+		if node.MemoryUtilization == nil {
+			node.MemoryUtilization = m.MemoryUtilization
+		}
+		if m.GPUName != "" && !slices.Contains(node.GPUModels, m.GPUName) {
+			node.GPUModels = append(node.GPUModels, m.GPUName)
+		}
+		if m.GPUUtilization != nil {
+			utilizationSum[key] += *m.GPUUtilization
+			utilizationCount[key]++
+		}
+	}
+
+	nodes := make([]models.NodeSummary, 0, len(nodeMap))
+	for key, node := range nodeMap {
+		if count := utilizationCount[key]; count > 0 {
+			average := utilizationSum[key] / float64(count)
+			node.GPUUtilization = &average
+		}
+		sort.Strings(node.GPUModels)
+		nodes = append(nodes, *node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Cluster != nodes[j].Cluster {
+			return nodes[i].Cluster < nodes[j].Cluster
+		}
+		return nodes[i].NodeName < nodes[j].NodeName
+	})
+
+	return nodes
+}
+
+// addInt returns sum plus value, treating a nil sum as zero and leaving it unchanged when value is nil.
+func addInt(sum, value *int) *int {
+	if value == nil {
+		return sum
+	}
+	total := *value
+	if sum != nil {
+		total += *sum
+	}
+	return &total
+}
@@ -1,13 +1,117 @@
 package timeutil
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
 
-// NowJST returns the current time in JST as "YYYY/MM/DD HH:MM:SS".
-func NowJST() string {
-	loc, err := time.LoadLocation("Asia/Tokyo")
+	// The runtime image ships without zoneinfo
+	_ "time/tzdata"
+)
+
+// legacyLayout is the "YYYY/MM/DD HH:MM:SS" format responses used before RFC 3339.
+const legacyLayout = "2006/01/02 15:04:05"
+
+// Format describes how timestamps are written in API responses.
+type Format struct {
+	Location *time.Location
+	// Legacy writes "YYYY/MM/DD HH:MM:SS" without a UTC offset instead of RFC 3339.
+	Legacy bool
+}
+
+// defaultFormat is the server-wide format, Asia/Tokyo in RFC 3339 until configured.
+var defaultFormat atomic.Pointer[Format]
+
+func init() {
+	// Always found with the embedded zoneinfo
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	SetDefault(Format{Location: loc})
+}
+
+// SetDefault sets the server-wide format, used for timestamps produced internally and for
+// requests that do not ask for a time zone.
+func SetDefault(f Format) {
+	if f.Location == nil {
+		f.Location = time.UTC
+	}
+	defaultFormat.Store(&f)
+}
+
+// Default returns the server-wide format.
+func Default() Format {
+	return *defaultFormat.Load()
+}
+
+// LoadLocation returns the location of an IANA time zone name such as "America/New_York" or "UTC".
+func LoadLocation(name string) (*time.Location, error) {
+	// "" and "Local" would silently resolve to the server's own zone
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Time returns t in the location and layout of f.
+func (f Format) Time(t time.Time) string {
+	t = t.In(f.Location)
+	if f.Legacy {
+		return t.Format(legacyLayout)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Convert rewrites a timestamp accepted by Parse in the location and layout of f.
+// Empty and unparsable values are returned unchanged.
+func (f Format) Convert(s string) string {
+	if s == "" {
+		return s
+	}
+	t, err := Parse(s)
 	if err != nil {
-		loc = time.FixedZone("JST", 9*60*60)
+		return s
 	}
+	return f.Time(t)
+}
+
+// FormatRFC3339 returns t as an RFC 3339 timestamp in the default location, the canonical
+// form timestamps are produced in before being converted for a response.
+func FormatRFC3339(t time.Time) string {
+	return t.In(Default().Location).Format(time.RFC3339)
+}
+
+// Now returns the current time formatted by FormatRFC3339.
+func Now() string {
+	return FormatRFC3339(time.Now())
+}
 
-	return time.Now().In(loc).Format("2006/01/02 15:04:05")
+// Parse parses an RFC 3339 timestamp, or a legacy "YYYY/MM/DD HH:MM:SS" one in the default location.
+func Parse(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(legacyLayout, s, Default().Location); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("timestamp is neither RFC 3339 nor YYYY/MM/DD HH:MM:SS")
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the format requested by the caller.
+func NewContext(ctx context.Context, f Format) context.Context {
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// FromContext returns the format carried by ctx, or the default one.
+func FromContext(ctx context.Context) Format {
+	if f, ok := ctx.Value(contextKey{}).(Format); ok {
+		return f
+	}
+	return Default()
 }
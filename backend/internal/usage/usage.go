@@ -0,0 +1,71 @@
+// Package usage aggregates GPU processes into per-user and per-namespace usage.
+package usage
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s-gpu-monitoring/internal/models"
+)
+
+// Unknown is the name processes are grouped under when the grouping field is empty.
+// The group is flagged Unattributed, keeping it apart from a user or namespace actually named so.
+const Unknown = "unknown"
+
+// ByUser aggregates processes by the user running them.
+func ByUser(processes []models.GPUProcess) []models.GPUUsage {
+	return aggregate(processes, func(p models.GPUProcess) string { return p.User })
+}
+
+// ByNamespace aggregates processes by the Kubernetes namespace of their pod.
+func ByNamespace(processes []models.GPUProcess) []models.GPUUsage {
+	return aggregate(processes, func(p models.GPUProcess) string { return p.Namespace })
+}
+
+// aggregate sums GPU memory and counts processes and distinct GPUs per group, largest memory first.
+func aggregate(processes []models.GPUProcess, groupOf func(models.GPUProcess) string) []models.GPUUsage {
+	usageMap := make(map[string]*models.GPUUsage)
+	gpus := make(map[string]map[string]bool)
+
+	for _, p := range processes {
+		// The unattributed group is keyed by "" so it never merges with a real group named Unknown
+		name := groupOf(p)
+
+		u, exists := usageMap[name]
+		if !exists {
+			u = &models.GPUUsage{Name: name}
+			if name == "" {
+				u.Name = Unknown
+				u.Unattributed = true
+			}
+			usageMap[name] = u
+			gpus[name] = make(map[string]bool)
+		}
+
+		u.GPUMemory += p.GPUMemory
+		u.ProcessCount++
+
+		gpuKey := fmt.Sprintf("%s:%s:%d", p.Cluster, p.NodeName, p.GPUIndex)
+		if !gpus[name][gpuKey] {
+			gpus[name][gpuKey] = true
+			u.GPUCount++
+		}
+	}
+
+	usages := make([]models.GPUUsage, 0, len(usageMap))
+	for _, u := range usageMap {
+		usages = append(usages, *u)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].GPUMemory != usages[j].GPUMemory {
+			return usages[i].GPUMemory > usages[j].GPUMemory
+		}
+		if usages[i].Name != usages[j].Name {
+			return usages[i].Name < usages[j].Name
+		}
+		return !usages[i].Unattributed && usages[j].Unattributed
+	})
+
+	return usages
+}
@@ -0,0 +1,347 @@
+package alerting_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/alerting"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+func gpu(node string, index, utilization, temperature, used, total int) models.GPUMetrics {
+	return models.GPUMetrics{
+		NodeName:       node,
+		GPUIndex:       index,
+		GPUName:        "NVIDIA Tesla V100",
+		GPUUtilization: ptr(float64(utilization)),
+		GPUTemperature: ptr(float64(temperature)),
+		GPUMemoryUsed:  ptr(used),
+		GPUMemoryTotal: ptr(total),
+	}
+}
+
+// TestEngine_Evaluate tests the pending, firing and resolved transitions of a rule with a hold duration
+func TestEngine_Evaluate(t *testing.T) {
+	engine := alerting.NewEngine([]alerting.Rule{
+		{
+			Name:       "HighTemperature",
+			Severity:   "warning",
+			For:        alerting.Duration(5 * time.Minute),
+			Conditions: []alerting.Condition{{Metric: alerting.MetricGPUTemperature, Op: ">", Threshold: 80}},
+		},
+	})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hot := []models.GPUMetrics{gpu("node1", 0, 50, 85, 1000, 16000), gpu("node1", 1, 50, 60, 1000, 16000)}
+
+	if changes := engine.Evaluate(hot, nil, start); len(changes) != 0 {
+		t.Fatalf("expected no notifications while pending, got %+v", changes)
+	}
+	alerts := engine.Alerts()
+	if len(alerts) != 1 || alerts[0].State != models.AlertStatePending || alerts[0].GPUIndex != 0 {
+		t.Fatalf("expected one pending alert on GPU 0, got %+v", alerts)
+	}
+
+	if changes := engine.Evaluate(hot, nil, start.Add(4*time.Minute)); len(changes) != 0 {
+		t.Fatalf("expected no notifications before the hold duration, got %+v", changes)
+	}
+
+	changes := engine.Evaluate(hot, nil, start.Add(5*time.Minute))
+	if len(changes) != 1 || changes[0].State != models.AlertStateFiring {
+		t.Fatalf("expected firing notification, got %+v", changes)
+	}
+	if changes[0].Values[alerting.MetricGPUTemperature] != 85 {
+		t.Errorf("expected observed temperature 85, got %v", changes[0].Values)
+	}
+
+	// Firing is only notified once
+	if changes := engine.Evaluate(hot, nil, start.Add(6*time.Minute)); len(changes) != 0 {
+		t.Fatalf("expected no repeated notification, got %+v", changes)
+	}
+
+	cool := []models.GPUMetrics{gpu("node1", 0, 50, 70, 1000, 16000), gpu("node1", 1, 50, 60, 1000, 16000)}
+	changes = engine.Evaluate(cool, nil, start.Add(7*time.Minute))
+	if len(changes) != 1 || changes[0].State != models.AlertStateResolved || changes[0].ResolvedAt == "" {
+		t.Fatalf("expected resolved notification, got %+v", changes)
+	}
+	if alerts := engine.Alerts(); len(alerts) != 0 {
+		t.Errorf("expected no active alerts, got %+v", alerts)
+	}
+
+	// A pending alert that clears is dropped without notification
+	engine.Evaluate(hot, nil, start.Add(8*time.Minute))
+	if changes := engine.Evaluate(cool, nil, start.Add(9*time.Minute)); len(changes) != 0 {
+		t.Errorf("expected pending alert to clear silently, got %+v", changes)
+	}
+}
+
+// TestEngine_IdleWithProcess tests rules combining GPU metrics with process data
+func TestEngine_IdleWithProcess(t *testing.T) {
+	engine := alerting.NewEngine([]alerting.Rule{
+		{
+			Name: "IdleWithMemoryHeld",
+			Conditions: []alerting.Condition{
+				{Metric: alerting.MetricGPUUtilization, Op: "<", Threshold: 5},
+				{Metric: alerting.MetricProcessGPUMemory, Op: ">", Threshold: 0},
+			},
+		},
+		{
+			Name:       "MemoryAlmostFull",
+			Conditions: []alerting.Condition{{Metric: alerting.MetricGPUMemoryPercent, Op: ">", Threshold: 95}},
+		},
+	})
+
+	metrics := []models.GPUMetrics{
+		gpu("node1", 0, 0, 40, 8000, 16000),
+		gpu("node1", 1, 0, 40, 0, 16000),
+		gpu("node2", 0, 90, 60, 15800, 16000),
+	}
+	processes := []models.GPUProcess{
+		{NodeName: "node1", GPUIndex: 0, PID: 1234, GPUMemory: 8000},
+		{NodeName: "node2", GPUIndex: 0, PID: 5678, GPUMemory: 15800},
+	}
+
+	changes := engine.Evaluate(metrics, processes, time.Now())
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 firing alerts, got %+v", changes)
+	}
+	if changes[0].Rule != "IdleWithMemoryHeld" || changes[0].NodeName != "node1" || changes[0].GPUIndex != 0 {
+		t.Errorf("expected idle alert on node1 GPU 0, got %+v", changes[0])
+	}
+	if changes[1].Rule != "MemoryAlmostFull" || changes[1].NodeName != "node2" {
+		t.Errorf("expected memory alert on node2, got %+v", changes[1])
+	}
+
+	// GPUs that disappear from the snapshot resolve their alerts
+	changes = engine.Evaluate(metrics[:2], processes, time.Now())
+	if len(changes) != 1 || changes[0].Rule != "MemoryAlmostFull" || changes[0].State != models.AlertStateResolved {
+		t.Errorf("expected memory alert to resolve, got %+v", changes)
+	}
+}
+
+// TestEngine_MissingMetric tests that a failed query neither resolves a firing alert nor starts a new one
+func TestEngine_MissingMetric(t *testing.T) {
+	engine := alerting.NewEngine([]alerting.Rule{
+		{
+			Name:       "HighTemperature",
+			Conditions: []alerting.Condition{{Metric: alerting.MetricGPUTemperature, Op: ">", Threshold: 80}},
+		},
+	})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if changes := engine.Evaluate([]models.GPUMetrics{gpu("node1", 0, 50, 85, 1000, 16000)}, nil, start); len(changes) != 1 {
+		t.Fatalf("expected firing notification, got %+v", changes)
+	}
+
+	// The temperature query failed, leaving the value nil in a partial snapshot
+	unknown := []models.GPUMetrics{gpu("node1", 0, 50, 0, 1000, 16000), gpu("node1", 1, 50, 0, 1000, 16000)}
+	for i := range unknown {
+		unknown[i].GPUTemperature = nil
+	}
+	if changes := engine.Evaluate(unknown, nil, start.Add(time.Minute)); len(changes) != 0 {
+		t.Fatalf("expected no notifications on missing data, got %+v", changes)
+	}
+	alerts := engine.Alerts()
+	if len(alerts) != 1 || alerts[0].State != models.AlertStateFiring || alerts[0].FiredAt != timeutil.FormatRFC3339(start) {
+		t.Fatalf("expected the alert to keep firing since the start, got %+v", alerts)
+	}
+
+	changes := engine.Evaluate([]models.GPUMetrics{gpu("node1", 0, 50, 60, 1000, 16000)}, nil, start.Add(2*time.Minute))
+	if len(changes) != 1 || changes[0].State != models.AlertStateResolved {
+		t.Errorf("expected resolution once the value is known again, got %+v", changes)
+	}
+}
+
+// TestEngine_Stale tests that stale GPUs and processes neither resolve a firing alert nor start a new one
+func TestEngine_Stale(t *testing.T) {
+	engine := alerting.NewEngine([]alerting.Rule{
+		{
+			Name:       "HighTemperature",
+			Conditions: []alerting.Condition{{Metric: alerting.MetricGPUTemperature, Op: ">", Threshold: 80}},
+		},
+		{
+			Name:       "Crowded",
+			Conditions: []alerting.Condition{{Metric: alerting.MetricProcessCount, Op: ">", Threshold: 1}},
+		},
+	})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if changes := engine.Evaluate([]models.GPUMetrics{gpu("node1", 0, 50, 85, 1000, 16000)}, nil, start); len(changes) != 1 {
+		t.Fatalf("expected firing notification, got %+v", changes)
+	}
+
+	// The exporter of node1 stopped reporting, and node2 has a stale process
+	old := gpu("node1", 0, 50, 60, 1000, 16000)
+	old.Stale = true
+	metrics := []models.GPUMetrics{old, gpu("node2", 0, 50, 60, 1000, 16000)}
+	processes := []models.GPUProcess{
+		{NodeName: "node2", GPUIndex: 0, PID: 1, GPUMemory: 500},
+		{NodeName: "node2", GPUIndex: 0, PID: 2, GPUMemory: 500, Stale: true},
+	}
+	if changes := engine.Evaluate(metrics, processes, start.Add(time.Minute)); len(changes) != 0 {
+		t.Fatalf("expected no notifications on stale data, got %+v", changes)
+	}
+	alerts := engine.Alerts()
+	if len(alerts) != 1 || alerts[0].Rule != "HighTemperature" || alerts[0].State != models.AlertStateFiring {
+		t.Fatalf("expected only the temperature alert to keep firing, got %+v", alerts)
+	}
+}
+
+// staleSource serves a fixed snapshot, marking it stale like the cache does when the upstream is down
+type staleSource struct {
+	metrics []models.GPUMetrics
+	stale   bool
+}
+
+func (s *staleSource) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	if s.stale {
+		report.FromContext(ctx).MarkStale(time.Now().Add(-time.Hour))
+	}
+	return s.metrics, nil
+}
+
+func (s *staleSource) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	return nil, nil
+}
+
+func (s *staleSource) Ping(ctx context.Context) error {
+	return nil
+}
+
+// TestEngine_RunStaleSnapshot tests that a stale snapshot served in place of fresh data is not evaluated
+func TestEngine_RunStaleSnapshot(t *testing.T) {
+	engine := alerting.NewEngine([]alerting.Rule{
+		{
+			Name:       "HighTemperature",
+			Conditions: []alerting.Condition{{Metric: alerting.MetricGPUTemperature, Op: ">", Threshold: 80}},
+		},
+	})
+	engine.Evaluate([]models.GPUMetrics{gpu("node1", 0, 50, 85, 1000, 16000)}, nil, time.Now())
+
+	// A cancelled context makes Run poll exactly once
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	src := &staleSource{metrics: []models.GPUMetrics{gpu("node1", 0, 50, 60, 1000, 16000)}, stale: true}
+	engine.Run(ctx, src, time.Hour)
+	if alerts := engine.Alerts(); len(alerts) != 1 || alerts[0].State != models.AlertStateFiring {
+		t.Fatalf("expected the alert to keep firing on a stale snapshot, got %+v", alerts)
+	}
+
+	src.stale = false
+	engine.Run(ctx, src, time.Hour)
+	if alerts := engine.Alerts(); len(alerts) != 0 {
+		t.Errorf("expected the alert to resolve on a fresh snapshot, got %+v", alerts)
+	}
+}
+
+// TestNotifiers tests the generic webhook and Slack-compatible payloads against a local stand-in
+func TestNotifiers(t *testing.T) {
+	bodies := make(chan []byte, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	alerts := []models.Alert{{
+		Rule:     "HighTemperature",
+		Severity: "critical",
+		State:    models.AlertStateFiring,
+		NodeName: "node1",
+		GPUIndex: 0,
+		Values:   map[string]float64{alerting.MetricGPUTemperature: 85},
+	}}
+
+	if err := alerting.NewWebhookNotifier(server.URL).Notify(context.Background(), alerts); err != nil {
+		t.Fatalf("webhook notify failed: %v", err)
+	}
+	var payload alerting.WebhookPayload
+	if err := json.Unmarshal(<-bodies, &payload); err != nil {
+		t.Fatalf("failed to decode webhook payload: %v", err)
+	}
+	if len(payload.Alerts) != 1 || payload.Alerts[0].Rule != "HighTemperature" {
+		t.Errorf("unexpected webhook payload %+v", payload)
+	}
+
+	if err := alerting.NewSlackNotifier(server.URL).Notify(context.Background(), alerts); err != nil {
+		t.Fatalf("slack notify failed: %v", err)
+	}
+	var message struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(<-bodies, &message); err != nil {
+		t.Fatalf("failed to decode slack payload: %v", err)
+	}
+	expected := "[FIRING] HighTemperature on node1 GPU 0 (critical): temperature=85"
+	if message.Text != expected {
+		t.Errorf("expected %q, got %q", expected, message.Text)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer failing.Close()
+	if err := alerting.NewWebhookNotifier(failing.URL).Notify(context.Background(), alerts); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
+
+// TestLoadConfig tests reading rules from YAML and reporting invalid rules together
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "rules.yaml")
+	os.WriteFile(valid, []byte(`
+rules:
+  - name: HighTemperature
+    for: 5m
+    conditions:
+      - metric: temperature
+        op: ">"
+        threshold: 80
+notifiers:
+  - type: slack
+    url: http://localhost/hook
+`), 0o644)
+
+	cfg, err := alerting.LoadConfig(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Rules) != 1 || time.Duration(cfg.Rules[0].For) != 5*time.Minute {
+		t.Errorf("unexpected rules %+v", cfg.Rules)
+	}
+
+	invalid := filepath.Join(dir, "rules.json")
+	os.WriteFile(invalid, []byte(`{"rules":[{"name":"A","conditions":[{"metric":"fan_speed","op":"~","threshold":1}]}],"notifiers":[{"type":"email"}]}`), 0o644)
+
+	_, err = alerting.LoadConfig(invalid)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"unknown metric", "unknown operator", "unknown type", "url is not set"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,358 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/auth"
+)
+
+// jwksServer is a local stand-in for an OIDC provider's JWKS endpoint, counting the requests it serves
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	mu       sync.Mutex
+	keys     []map[string]string
+	delay    time.Duration
+	failing  bool
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		time.Sleep(s.delay)
+		if s.failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) addRSA(kid string, key *rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   b64(key.N.Bytes()),
+		"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+	})
+}
+
+func (s *jwksServer) addEC(kid string, key *ecdsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   b64(key.X.FillBytes(make([]byte, 32))),
+		"y":   b64(key.Y.FillBytes(make([]byte, 32))),
+	})
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sign builds a JWT with the given header and claims signed by key
+func sign(t *testing.T, alg, kid string, claims map[string]interface{}, key crypto.Signer) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"sub": "alice",
+		"aud": []string{"gpu-monitoring", "other"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+// TestJWTVerifier tests signature and claim validation against a local JWKS
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	jwks := newJWKSServer(t)
+	jwks.addRSA("rsa-1", &rsaKey.PublicKey)
+	jwks.addEC("ec-1", &ecKey.PublicKey)
+
+	verifier := auth.NewJWTVerifier(auth.JWTOptions{
+		JWKSURL:  jwks.URL,
+		Issuer:   "https://issuer.example.com",
+		Audience: "gpu-monitoring",
+	})
+
+	with := func(key string, value interface{}) map[string]interface{} {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"RS256", sign(t, "RS256", "rsa-1", validClaims(), rsaKey), false},
+		{"ES256", sign(t, "ES256", "ec-1", validClaims(), ecKey), false},
+		{"string audience", sign(t, "RS256", "rsa-1", with("aud", "gpu-monitoring"), rsaKey), false},
+		{"expired", sign(t, "RS256", "rsa-1", with("exp", time.Now().Add(-time.Hour).Unix()), rsaKey), true},
+		{"missing exp", sign(t, "RS256", "rsa-1", with("exp", nil), rsaKey), true},
+		{"not yet valid", sign(t, "RS256", "rsa-1", with("nbf", time.Now().Add(time.Hour).Unix()), rsaKey), true},
+		{"wrong issuer", sign(t, "RS256", "rsa-1", with("iss", "https://evil.example.com"), rsaKey), true},
+		{"wrong audience", sign(t, "RS256", "rsa-1", with("aud", "someone-else"), rsaKey), true},
+		{"signed by unknown key", sign(t, "RS256", "rsa-1", validClaims(), otherKey), true},
+		{"algorithm mismatch", sign(t, "ES256", "rsa-1", validClaims(), ecKey), true},
+		{"none algorithm", b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{"sub":"alice"}`)) + ".", true},
+		{"malformed", "not-a-jwt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, auth.ErrInvalidToken) {
+					t.Errorf("expected ErrInvalidToken, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.Subject != "alice" || principal.Method != "oidc" {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+		})
+	}
+}
+
+// TestMiddleware tests protected and exempt routes with static tokens
+func TestMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	os.WriteFile(path, []byte("# dashboard\ndashboard:s3cret\n\nanonymous-token\n"), 0o600)
+
+	tokens, err := auth.LoadTokenFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var principal auth.Principal
+	handler := auth.Middleware(tokens)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = auth.PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		expectedCode  int
+		expectedUser  string
+	}{
+		{"missing token", "GET", "/api/v1/gpu/processes", "", http.StatusUnauthorized, ""},
+		{"wrong token", "GET", "/api/v1/gpu/processes", "Bearer nope", http.StatusUnauthorized, ""},
+		{"wrong scheme", "GET", "/api/v1/gpu/processes", "Basic s3cret", http.StatusUnauthorized, ""},
+		{"named token", "GET", "/api/v1/gpu/processes", "Bearer s3cret", http.StatusOK, "dashboard"},
+		{"unnamed token", "GET", "/api/v1/gpu/metrics", "bearer anonymous-token", http.StatusOK, "token-4"},
+		{"exporter protected", "GET", "/metrics", "", http.StatusUnauthorized, ""},
+		{"healthz exempt", "GET", "/api/healthz", "", http.StatusOK, ""},
+		{"preflight exempt", "OPTIONS", "/api/v1/gpu/metrics", "", http.StatusOK, ""},
+		{"static files exempt", "GET", "/index.html", "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = auth.Principal{}
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header")
+			}
+			if principal.Subject != tt.expectedUser {
+				t.Errorf("expected principal %q, got %q", tt.expectedUser, principal.Subject)
+			}
+		})
+	}
+}
+
+// TestJWTVerifier_UnknownKey tests that unknown signing keys do not trigger a refetch on every request
+func TestJWTVerifier_UnknownKey(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := newJWKSServer(t)
+	jwks.addRSA("old", &oldKey.PublicKey)
+
+	verifier := auth.NewJWTVerifier(auth.JWTOptions{JWKSURL: jwks.URL})
+	if _, err := verifier.Verify(context.Background(), sign(t, "RS256", "old", validClaims(), oldKey)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Within the minimum refresh interval an unknown kid is rejected without refetching
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks.addRSA("new", &newKey.PublicKey)
+	if _, err := verifier.Verify(context.Background(), sign(t, "RS256", "new", validClaims(), newKey)); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("expected unknown key to be rejected until refresh, got %v", err)
+	}
+}
+
+// TestJWTVerifier_SharedFetch tests that concurrent requests share a single fetch of the key set
+func TestJWTVerifier_SharedFetch(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := newJWKSServer(t)
+	jwks.addRSA("rsa-1", &key.PublicKey)
+	jwks.delay = 50 * time.Millisecond
+
+	verifier := auth.NewJWTVerifier(auth.JWTOptions{JWKSURL: jwks.URL})
+	token := sign(t, "RS256", "rsa-1", validClaims(), key)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := verifier.Verify(context.Background(), token); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := jwks.requests.Load(); got != 1 {
+		t.Errorf("expected a single JWKS request, got %d", got)
+	}
+}
+
+// TestJWTVerifier_FetchFailure tests that failed fetches are rate-limited rather than retried on every request
+func TestJWTVerifier_FetchFailure(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := newJWKSServer(t)
+	jwks.addRSA("rsa-1", &key.PublicKey)
+	jwks.failing = true
+
+	verifier := auth.NewJWTVerifier(auth.JWTOptions{JWKSURL: jwks.URL})
+	token := sign(t, "RS256", "rsa-1", validClaims(), key)
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), token); err == nil {
+			t.Fatal("expected an error while the JWKS endpoint is down")
+		}
+	}
+
+	if got := jwks.requests.Load(); got != 1 {
+		t.Errorf("expected failed fetches to be rate-limited to 1 request, got %d", got)
+	}
+}
+
+// TestPrincipalGroups tests that groups are read from token files and the configured JWT claim
+func TestPrincipalGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	os.WriteFile(path, []byte("ops:admin, sre:ops-token\n"), 0o600)
+
+	tokens, err := auth.LoadTokenFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	principal, err := tokens.Verify(context.Background(), "ops-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if principal.Subject != "ops" || !slices.Equal(principal.Groups, []string{"admin", "sre"}) {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := newJWKSServer(t)
+	jwks.addRSA("rsa-1", &key.PublicKey)
+	verifier := auth.NewJWTVerifier(auth.JWTOptions{JWKSURL: jwks.URL, GroupsClaim: "roles"})
+
+	tests := []struct {
+		name     string
+		roles    interface{}
+		expected []string
+	}{
+		{"array", []string{"gpu-admin", "viewer"}, []string{"gpu-admin", "viewer"}},
+		{"string", "gpu-admin", []string{"gpu-admin"}},
+		{"missing", nil, nil},
+		{"malformed", 42, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validClaims()
+			if tt.roles != nil {
+				c["roles"] = tt.roles
+			}
+			principal, err := verifier.Verify(context.Background(), sign(t, "RS256", "rsa-1", c, key))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(principal.Groups, tt.expected) {
+				t.Errorf("expected groups %v, got %v", tt.expected, principal.Groups)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+)
+
+// mockFetcher implements source.MetricsSource with controllable latency and failures
+type mockFetcher struct {
+	metricsCalls   atomic.Int32
+	processesCalls atomic.Int32
+	fail           atomic.Bool
+	partial        atomic.Bool
+	release        chan struct{}
+}
+
+func (m *mockFetcher) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	m.metricsCalls.Add(1)
+	if m.release != nil {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if m.fail.Load() {
+		return nil, errors.New("mock prometheus error")
+	}
+	if m.partial.Load() {
+		report.FromContext(ctx).AddWarning("temperature", errors.New("mock temperature error"))
+	}
+	return []models.GPUMetrics{{NodeName: "node1", GPUIndex: 0}}, nil
+}
+
+func (m *mockFetcher) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	m.processesCalls.Add(1)
+	if m.fail.Load() {
+		return nil, errors.New("mock prometheus process error")
+	}
+	return []models.GPUProcess{{NodeName: "node1", PID: 1234}}, nil
+}
+
+func (m *mockFetcher) Ping(ctx context.Context) error {
+	return nil
+}
+
+// TestCache_TTL tests that fresh snapshots are served without contacting the upstream
+func TestCache_TTL(t *testing.T) {
+	fetcher := &mockFetcher{}
+	c := cache.New(fetcher, cache.Options{TTL: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetGPUMetrics(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := c.GetGPUProcesses(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := fetcher.metricsCalls.Load(); got != 1 {
+		t.Errorf("expected 1 metrics fetch, got %d", got)
+	}
+	if got := fetcher.processesCalls.Load(); got != 1 {
+		t.Errorf("expected 1 processes fetch, got %d", got)
+	}
+
+	if stats := c.Stats(); stats.Hits != 8 || stats.Misses != 2 || stats.Stale != 0 {
+		t.Errorf("expected 8 hits and 2 misses, got %+v", stats)
+	}
+}
+
+// TestCache_ZeroTTL tests that a zero TTL refreshes on every call
+func TestCache_ZeroTTL(t *testing.T) {
+	fetcher := &mockFetcher{}
+	c := cache.New(fetcher, cache.Options{})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetGPUMetrics(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := fetcher.metricsCalls.Load(); got != 3 {
+		t.Errorf("expected 3 metrics fetches, got %d", got)
+	}
+}
+
+// TestCache_Coalescing tests that concurrent misses share a single upstream fetch
+func TestCache_Coalescing(t *testing.T) {
+	fetcher := &mockFetcher{release: make(chan struct{})}
+	c := cache.New(fetcher, cache.Options{TTL: time.Hour})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metrics, err := c.GetGPUMetrics(context.Background())
+			if err == nil && len(metrics) != 1 {
+				err = errors.New("unexpected metrics")
+			}
+			errs <- err
+		}()
+	}
+
+	// Give every caller a chance to join the in-flight fetch
+	time.Sleep(50 * time.Millisecond)
+	close(fetcher.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if got := fetcher.metricsCalls.Load(); got != 1 {
+		t.Errorf("expected 1 coalesced fetch, got %d", got)
+	}
+}
+
+// TestCache_CallerCancellation tests that one caller giving up does not fail the shared fetch
+func TestCache_CallerCancellation(t *testing.T) {
+	fetcher := &mockFetcher{release: make(chan struct{})}
+	c := cache.New(fetcher, cache.Options{TTL: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := c.GetGPUMetrics(ctx)
+		cancelled <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	close(fetcher.release)
+	metrics, err := c.GetGPUMetrics(context.Background())
+	if err != nil || len(metrics) != 1 {
+		t.Errorf("expected shared fetch to complete, got %v, %v", metrics, err)
+	}
+	if got := fetcher.metricsCalls.Load(); got != 1 {
+		t.Errorf("expected 1 fetch, got %d", got)
+	}
+}
+
+// TestCache_StaleOnError tests that the last known snapshot is served and marked stale when the upstream fails
+func TestCache_StaleOnError(t *testing.T) {
+	fetcher := &mockFetcher{}
+	c := cache.New(fetcher, cache.Options{MaxStale: time.Hour})
+
+	if _, err := c.GetGPUMetrics(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fetcher.fail.Store(true)
+
+	ctx, rep := report.NewContext(context.Background())
+	metrics, err := c.GetGPUMetrics(ctx)
+	if err != nil {
+		t.Fatalf("expected stale snapshot, got error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Errorf("expected stale metrics, got %d", len(metrics))
+	}
+
+	stale, fetchedAt := rep.Stale()
+	if !stale {
+		t.Error("expected response to be marked stale")
+	}
+	if fetchedAt.IsZero() {
+		t.Error("expected stale snapshot time to be recorded")
+	}
+
+	// Without a previous snapshot the error is returned as is
+	if _, err := c.GetGPUProcesses(context.Background()); err == nil {
+		t.Error("expected error without a previous snapshot")
+	}
+}
+
+// TestCache_MaxStale tests that snapshots older than MaxStale are no longer served
+func TestCache_MaxStale(t *testing.T) {
+	fetcher := &mockFetcher{}
+	c := cache.New(fetcher, cache.Options{MaxStale: 10 * time.Millisecond})
+
+	if _, err := c.GetGPUMetrics(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fetcher.fail.Store(true)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := c.GetGPUMetrics(context.Background()); err == nil {
+		t.Error("expected error once the snapshot exceeded MaxStale")
+	}
+}
+
+// TestCache_Warnings tests that warnings recorded while fetching are replayed to every caller served the snapshot
+func TestCache_Warnings(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.partial.Store(true)
+	c := cache.New(fetcher, cache.Options{TTL: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		ctx, rep := report.NewContext(context.Background())
+		if _, err := c.GetGPUMetrics(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		warnings := rep.Warnings()
+		if len(warnings) != 1 || warnings[0].Query != "temperature" || warnings[0].Message != "mock temperature error" {
+			t.Errorf("call %d: unexpected warnings %+v", i, warnings)
+		}
+	}
+
+	// Warnings of the cached snapshot also accompany it when served stale
+	c = cache.New(fetcher, cache.Options{})
+	if _, err := c.GetGPUMetrics(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fetcher.fail.Store(true)
+
+	ctx, rep := report.NewContext(context.Background())
+	if _, err := c.GetGPUMetrics(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stale, _ := rep.Stale(); !stale || len(rep.Warnings()) != 1 {
+		t.Errorf("expected stale snapshot with its warning, got stale %v warnings %+v", stale, rep.Warnings())
+	}
+}
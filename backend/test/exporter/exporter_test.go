@@ -0,0 +1,136 @@
+package exporter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/exporter"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/redact"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// TestExporter_WriteTo tests the derived series and query instrumentation in the text format
+func TestExporter_WriteTo(t *testing.T) {
+	responses := map[string]string{
+		"gpu_metrics_utilization_percent": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0"},"value":[1640995200,"0"]},
+			{"metric":{"hostname":"node1","gpu_id":"1"},"value":[1640995200,"1"]},
+			{"metric":{"hostname":"node1","gpu_id":"2"},"value":[1640995200,"90"]}]}}`,
+		"gpu_metrics_used_memory": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0"},"value":[1640995200,"0"]},
+			{"metric":{"hostname":"node1","gpu_id":"1"},"value":[1640995200,"4096"]},
+			{"metric":{"hostname":"node1","gpu_id":"2"},"value":[1640995200,"8192"]}]}}`,
+		"gpu_process_gpu_memory": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"1","pid":"1","user":"alice"},"value":[1640995200,"4096"]},
+			{"metric":{"hostname":"node1","gpu_id":"2","pid":"2","user":"b\"ob"},"value":[1640995200,"8192"]}]}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			http.Error(w, "Unknown query", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	queries := exporter.NewQueryStats()
+	client := prometheus.NewClient(server.URL, prometheus.WithSchema(prometheus.Schema{
+		Labels: prometheus.SchemaCustom.Labels,
+		Metrics: map[string]prometheus.Field{
+			prometheus.FieldGPUUtilization: {Query: "gpu_metrics_utilization_percent"},
+			prometheus.FieldGPUMemoryUsed:  {Query: "gpu_metrics_used_memory"},
+			prometheus.FieldGPUTemperature: {Query: "gpu_metrics_missing"},
+		},
+		Processes: prometheus.SchemaCustom.Processes,
+	}), prometheus.WithObserver(queries))
+	snapshots := cache.New(client, cache.Options{})
+
+	// The failing temperature query only leaves temperature out of the first snapshot
+	var out strings.Builder
+	if err := exporter.New(snapshots, snapshots, queries).WriteTo(context.Background(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "gpu_monitoring_snapshot_up 1\n") || !strings.Contains(out.String(), "gpu_monitoring_gpus 3\n") {
+		t.Errorf("expected partial snapshot, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `gpu_monitoring_upstream_query_errors_total{query="temperature"} 1`) {
+		t.Errorf("expected temperature query error, got:\n%s", out.String())
+	}
+
+	responses["gpu_metrics_missing"] = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+	out.Reset()
+	if err := exporter.New(snapshots, snapshots, queries).WriteTo(context.Background(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"# TYPE gpu_monitoring_snapshot_up gauge\ngpu_monitoring_snapshot_up 1\n",
+		"gpu_monitoring_gpus 3\n",
+		"gpu_monitoring_free_gpus 1\n",
+		"gpu_monitoring_low_utilization_gpus 1\n",
+		`gpu_monitoring_user_gpu_memory{user="b\"ob"} 8192`,
+		`gpu_monitoring_user_gpu_memory{user="alice"} 4096`,
+		`gpu_monitoring_cache_requests_total{result="miss"} 4`,
+		"# TYPE gpu_monitoring_upstream_query_duration_seconds histogram\n",
+		`gpu_monitoring_upstream_query_duration_seconds_count{query="gpu_utilization"} 2`,
+		`gpu_monitoring_upstream_query_duration_seconds_bucket{query="gpu_utilization",le="+Inf"} 2`,
+		`gpu_monitoring_upstream_query_errors_total{query="gpu_memory"} 0`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestExporter_WithoutCache tests that cache and query series are optional
+func TestExporter_WithoutCache(t *testing.T) {
+	src := source.NewFixture([]models.GPUMetrics{{NodeName: "node1"}}, nil)
+
+	var out strings.Builder
+	if err := exporter.New(src, nil, nil).WriteTo(context.Background(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "cache") || strings.Contains(out.String(), "upstream") {
+		t.Errorf("expected no cache or query series, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "gpu_monitoring_gpus 1\n") {
+		t.Errorf("expected gpu count, got:\n%s", out.String())
+	}
+}
+
+// TestExporter_Redaction tests that user labels are redacted as for unauthenticated callers
+func TestExporter_Redaction(t *testing.T) {
+	policy, err := redact.NewPolicy(redact.Config{
+		Default: redact.Fields{User: redact.ModeMask},
+		Rules:   []redact.Rule{{Groups: []string{"admins"}, Fields: redact.Fields{User: redact.ModeShow}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	src := source.NewFixture(nil, []models.GPUProcess{
+		{NodeName: "node1", GPUIndex: 0, PID: 1, User: "alice", GPUMemory: 1024},
+		{NodeName: "node1", GPUIndex: 1, PID: 2, User: "bob", GPUMemory: 2048},
+	})
+
+	var out strings.Builder
+	if err := exporter.New(src, nil, nil, exporter.WithRedaction(policy)).WriteTo(context.Background(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "alice") || strings.Contains(out.String(), "bob") {
+		t.Errorf("expected user names to be masked, got:\n%s", out.String())
+	}
+	want := fmt.Sprintf("gpu_monitoring_user_gpu_memory{user=%q} 3072\n", redact.Masked)
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("expected masked users in one series %q, got:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,200 @@
+package federation_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/federation"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/report"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// downSource fails every call, as an unreachable Prometheus would
+type downSource struct{}
+
+func (downSource) GetGPUMetrics(context.Context) ([]models.GPUMetrics, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (downSource) GetGPUProcesses(context.Context) ([]models.GPUProcess, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (downSource) Ping(context.Context) error {
+	return errors.New("connection refused")
+}
+
+// warningSource serves a fixture and records a warning on every metrics read
+type warningSource struct {
+	*source.Fixture
+}
+
+func (s warningSource) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	report.FromContext(ctx).AddWarning("gpu_temperature", errors.New("query timed out"))
+	return s.Fixture.GetGPUMetrics(ctx)
+}
+
+// newFixture returns a source with one GPU and one process on node
+func newFixture(node string) *source.Fixture {
+	return source.NewFixture(
+		[]models.GPUMetrics{{NodeName: node, GPUIndex: 0, GPUName: "NVIDIA A100"}},
+		[]models.GPUProcess{{NodeName: node, GPUIndex: 0, PID: 1234}},
+	)
+}
+
+// TestFederation_GetGPUMetrics tests that results are tagged with their cluster in configuration order
+func TestFederation_GetGPUMetrics(t *testing.T) {
+	f := federation.New([]federation.Cluster{
+		{Name: "tokyo", Source: newFixture("node1")},
+		{Name: "osaka", Source: newFixture("node1")},
+	})
+
+	ctx, rep := report.NewContext(context.Background())
+	metrics, err := f.GetGPUMetrics(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 2 || metrics[0].Cluster != "tokyo" || metrics[1].Cluster != "osaka" {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+
+	processes, err := f.GetGPUProcesses(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(processes) != 2 || processes[0].Cluster != "tokyo" || processes[1].Cluster != "osaka" {
+		t.Errorf("unexpected processes %+v", processes)
+	}
+
+	want := []models.ClusterStatus{
+		{Name: "tokyo", Status: models.ClusterStatusOK},
+		{Name: "osaka", Status: models.ClusterStatusOK},
+	}
+	if got := rep.Clusters(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected statuses %+v, got %+v", want, got)
+	}
+}
+
+// TestFederation_ClusterDown tests that an unreachable cluster is reported without failing the others
+func TestFederation_ClusterDown(t *testing.T) {
+	f := federation.New([]federation.Cluster{
+		{Name: "tokyo", Source: warningSource{newFixture("node1")}},
+		{Name: "osaka", Source: downSource{}},
+	})
+
+	ctx, rep := report.NewContext(context.Background())
+	metrics, err := f.GetGPUMetrics(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].Cluster != "tokyo" {
+		t.Errorf("expected metrics of the reachable cluster only, got %+v", metrics)
+	}
+
+	statuses := rep.Clusters()
+	if len(statuses) != 2 || statuses[0].Status != models.ClusterStatusOK || statuses[1].Status != models.ClusterStatusError {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+	if !strings.Contains(statuses[1].Error, "connection refused") {
+		t.Errorf("expected the cause in the status, got %q", statuses[1].Error)
+	}
+
+	warnings := rep.Warnings()
+	if len(warnings) != 1 || warnings[0].Cluster != "tokyo" || warnings[0].Query != "gpu_temperature" {
+		t.Errorf("expected the warning attributed to its cluster, got %+v", warnings)
+	}
+
+	if err := f.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed while one cluster is up, got %v", err)
+	}
+}
+
+// TestFederation_AllClustersDown tests that an error is returned when no cluster answers
+func TestFederation_AllClustersDown(t *testing.T) {
+	f := federation.New([]federation.Cluster{
+		{Name: "tokyo", Source: downSource{}},
+		{Name: "osaka", Source: downSource{}},
+	})
+
+	_, err := f.GetGPUMetrics(context.Background())
+	if err == nil {
+		t.Fatal("expected error when every cluster is down")
+	}
+	for _, name := range []string{"tokyo", "osaka"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected error to mention %q, got %v", name, err)
+		}
+	}
+}
+
+// TestFederation_Unsupported tests that optional capabilities are served by the clusters that have them
+func TestFederation_Unsupported(t *testing.T) {
+	f := federation.New([]federation.Cluster{
+		{Name: "tokyo", Source: newFixture("node1")},
+		{Name: "osaka", Source: downSource{}},
+	})
+
+	history, err := f.GetGPUMetricsHistory(context.Background(), models.MetricsQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].Cluster != "tokyo" {
+		t.Errorf("expected the series of tokyo only, got %+v", history)
+	}
+
+	only := federation.New([]federation.Cluster{{Name: "osaka", Source: downSource{}}})
+	if _, err := only.GetGPUUtilization(context.Background()); !errors.Is(err, source.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+// TestLoadConfig tests reading clusters from YAML and reporting invalid entries together
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "clusters.yaml")
+	os.WriteFile(valid, []byte(`
+clusters:
+  - name: tokyo
+    url: http://prometheus.tokyo:9090
+    tenant_id: team-a
+    bearer_token_file: /var/run/secrets/tokyo/token
+  - name: osaka
+    url: https://prometheus.osaka.example.com
+`), 0o644)
+
+	cfg, err := federation.LoadConfig(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Clusters) != 2 || cfg.Clusters[1].Name != "osaka" {
+		t.Errorf("unexpected clusters %+v", cfg.Clusters)
+	}
+	if cfg.Clusters[0].TenantID != "team-a" || cfg.Clusters[0].BearerTokenFile != "/var/run/secrets/tokyo/token" {
+		t.Errorf("expected connection settings of tokyo, got %+v", cfg.Clusters[0].TransportConfig)
+	}
+
+	invalid := filepath.Join(dir, "clusters.json")
+	os.WriteFile(invalid, []byte(`{"clusters":[{"name":"a b","url":"http://x"},{"name":"c","url":"ftp://x"},{"name":"c","url":"http://y","cert_file":"/tls.crt"}]}`), 0o644)
+
+	_, err = federation.LoadConfig(invalid)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"invalid name", "invalid url", "duplicate cluster name", "key_file"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.yaml")
+	os.WriteFile(empty, []byte("clusters: []\n"), 0o644)
+	if _, err := federation.LoadConfig(empty); err == nil {
+		t.Error("expected error for an empty cluster list")
+	}
+}
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/federation"
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// newFederation returns a cached federation of a healthy "tokyo" and an unreachable "osaka" cluster
+func newFederation() source.MetricsSource {
+	tokyo := source.NewFixture(
+		[]models.GPUMetrics{{NodeName: "node1", GPUIndex: 0}, {NodeName: "node2", GPUIndex: 0}},
+		[]models.GPUProcess{{NodeName: "node1", GPUIndex: 0, PID: 1}},
+	)
+	osaka := source.NewFixture(nil, nil)
+	osaka.Err = errors.New("connection refused")
+
+	return cache.New(federation.New([]federation.Cluster{
+		{Name: "tokyo", Source: tokyo},
+		{Name: "osaka", Source: osaka},
+	}), cache.Options{})
+}
+
+func TestGetGPUMetrics_Cluster(t *testing.T) {
+	handler := handlers.NewGPUHandler(newFederation())
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics", nil)
+	w := httptest.NewRecorder()
+	handler.GetGPUMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d while one cluster is up, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data     []models.GPUMetrics    `json:"data"`
+		Clusters []models.ClusterStatus `json:"clusters"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Data) != 2 || response.Data[0].Cluster != "tokyo" {
+		t.Errorf("expected metrics tagged with tokyo, got %+v", response.Data)
+	}
+	if len(response.Clusters) != 2 || response.Clusters[1].Status != models.ClusterStatusError {
+		t.Errorf("expected osaka to be reported as failed, got %+v", response.Clusters)
+	}
+
+	// Selecting the failed cluster returns no data and only its status
+	req = httptest.NewRequest("GET", "/api/v1/gpu/metrics?cluster=osaka", nil)
+	w = httptest.NewRecorder()
+	handler.GetGPUMetrics(w, req)
+
+	response.Data, response.Clusters = nil, nil
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Data) != 0 || len(response.Clusters) != 1 || response.Clusters[0].Name != "osaka" {
+		t.Errorf("expected only osaka, got data %+v clusters %+v", response.Data, response.Clusters)
+	}
+}
+
+func TestGetGPUNodes_UnknownCluster(t *testing.T) {
+	tests := []struct {
+		name   string
+		source source.MetricsSource
+	}{
+		{name: "federated", source: newFederation()},
+		{name: "single Prometheus", source: source.NewFixture(nil, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.NewGPUHandler(tt.source)
+
+			req := httptest.NewRequest("GET", "/api/v1/gpu/nodes?cluster=nagoya", nil)
+			w := httptest.NewRecorder()
+			handler.GetGPUNodes(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+// TestHealthCheck_Clusters tests that health checks report every cluster
+func TestHealthCheck_Clusters(t *testing.T) {
+	handler := handlers.NewGPUHandler(newFederation())
+
+	req := httptest.NewRequest("GET", "/api/healthz", nil)
+	w := httptest.NewRecorder()
+	handler.HealthCheck(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response models.APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Clusters) != 2 || response.Clusters[0].Status != models.ClusterStatusOK {
+		t.Errorf("unexpected cluster statuses %+v", response.Clusters)
+	}
+}
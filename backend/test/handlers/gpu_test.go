@@ -1,7 +1,6 @@
 package handlers_test
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -10,197 +9,53 @@ import (
 	"time"
 
 	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/middleware"
 	"k8s-gpu-monitoring/internal/models"
 	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/source"
 )
 
-// PrometheusClient interface for testing
-type PrometheusClient interface {
-	GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error)
-	GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error)
-	Query(ctx context.Context, query string) (*prometheus.PrometheusResponse, error)
-}
-
-// mockPrometheusClient implements PrometheusClient interface for testing
-type mockPrometheusClient struct {
-	shouldReturnError  bool
-	shouldProcessError bool
-}
-
-func (m *mockPrometheusClient) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
-	if m.shouldReturnError {
-		return nil, errors.New("mock prometheus error")
-	}
-
-	return []models.GPUMetrics{
-		{
-			NodeName:          "node1",
-			GPUIndex:          0,
-			GPUName:           "NVIDIA Tesla V100",
-			GPUUtilization:    75,
-			GPUMemoryUsed:     8192,
-			GPUMemoryTotal:    16384,
-			GPUMemoryFree:     8192,
-			CPUUtilization:    25,
-			MemoryUtilization: 50,
-			GPUTemperature:    65,
-			Timestamp:         "2024/01/01 12:00:00",
-		},
-	}, nil
-}
-
-func (m *mockPrometheusClient) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
-	if m.shouldProcessError {
-		return nil, errors.New("mock prometheus process error")
-	}
-
-	return []models.GPUProcess{
-		{
-			NodeName:    "node1",
-			GPUIndex:    0,
-			PID:         1234,
-			ProcessName: "python",
-			User:        "user1",
-			Command:     "python train.py",
-			GPUMemory:   1024,
-			Timestamp:   "2024/01/01 12:00:00",
-		},
-	}, nil
-}
-
-func (m *mockPrometheusClient) Query(ctx context.Context, query string) (*prometheus.PrometheusResponse, error) {
-	if m.shouldReturnError {
-		return nil, errors.New("mock prometheus error")
-	}
-
-	return &prometheus.PrometheusResponse{
-		Status: "success",
-		Data: struct {
-			ResultType string `json:"resultType"`
-			Result     []struct {
-				Metric map[string]string `json:"metric"`
-				Value  []interface{}     `json:"value"`
-			} `json:"result"`
-		}{
-			ResultType: "vector",
-			Result: []struct {
-				Metric map[string]string `json:"metric"`
-				Value  []interface{}     `json:"value"`
-			}{
-				{
-					Metric: map[string]string{
-						"hostname": "node1",
-						"gpu_id":   "0",
-					},
-					Value: []interface{}{
-						1234567890.0,
-						"75.5",
-					},
-				},
+// newMockSource returns a fixture source with one GPU and one process, failing with err when set
+func newMockSource(err error) *source.Fixture {
+	fixture := source.NewFixture(
+		[]models.GPUMetrics{
+			{
+				NodeName:          "node1",
+				GPUIndex:          0,
+				GPUName:           "NVIDIA Tesla V100",
+				GPUUtilization:    ptr(75.0),
+				GPUMemoryUsed:     ptr(8192),
+				GPUMemoryTotal:    ptr(16384),
+				GPUMemoryFree:     ptr(8192),
+				CPUUtilization:    ptr(25.0),
+				MemoryUtilization: ptr(50.0),
+				GPUTemperature:    ptr(65.0),
+				Timestamp:         "2024/01/01 12:00:00",
 			},
 		},
-	}, nil
-}
-
-// TestableGPUHandler wraps the real GPUHandler to allow dependency injection for testing
-type TestableGPUHandler struct {
-	*handlers.GPUHandler
-	mockClient PrometheusClient
-}
-
-// Create a new testable GPU handler that uses mock client
-func newTestableGPUHandler(mockClient PrometheusClient) *TestableGPUHandler {
-	// Create a real prometheus client just for structure, but we'll override methods
-	realClient := prometheus.NewClient("http://localhost:9090")
-	handler := handlers.NewGPUHandler(realClient)
-
-	return &TestableGPUHandler{
-		GPUHandler: handler,
-		mockClient: mockClient,
-	}
-}
-
-// Override methods to use mock client
-func (h *TestableGPUHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	_, err := h.mockClient.Query(ctx, "up")
-	if err != nil {
-		response := models.APIResponse{
-			Success: false,
-			Error:   "Prometheus connection failed",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	response := models.APIResponse{
-		Success: true,
-		Message: "Service is healthy",
-		Data: map[string]interface{}{
-			"status":    "healthy",
-			"timestamp": "2024/01/01 12:00:00",
-			"version":   "1.0.0",
+		[]models.GPUProcess{
+			{
+				NodeName:    "node1",
+				GPUIndex:    0,
+				PID:         1234,
+				ProcessName: "python",
+				User:        "user1",
+				Command:     "python train.py",
+				GPUMemory:   1024,
+				Timestamp:   "2024/01/01 12:00:00",
+			},
 		},
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	)
+	fixture.Err = err
+	return fixture
 }
 
-func (h *TestableGPUHandler) GetGPUMetrics(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
-
-	metrics, err := h.mockClient.GetGPUMetrics(ctx)
-	if err != nil {
-		response := models.APIResponse{
-			Success: false,
-			Error:   "Failed to retrieve GPU metrics",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+// mockError returns a mock error when shouldFail is set
+func mockError(shouldFail bool) error {
+	if shouldFail {
+		return errors.New("mock source error")
 	}
-
-	response := models.APIResponse{
-		Success: true,
-		Data:    metrics,
-		Message: "GPU metrics retrieved successfully",
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-}
-
-func (h *TestableGPUHandler) GetGPUProcesses(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
-
-	processes, err := h.mockClient.GetGPUProcesses(ctx)
-	if err != nil {
-		response := models.APIResponse{
-			Success: false,
-			Error:   "Failed to retrieve GPU processes",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	response := models.APIResponse{
-		Success: true,
-		Data:    processes,
-		Message: "GPU processes retrieved successfully",
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	return nil
 }
 
 func TestHealthCheck(t *testing.T) {
@@ -215,7 +70,7 @@ func TestHealthCheck(t *testing.T) {
 			expectedCode: http.StatusOK,
 		},
 		{
-			name:         "prometheus connection error",
+			name:         "source connection error",
 			mockError:    true,
 			expectedCode: http.StatusServiceUnavailable,
 		},
@@ -223,8 +78,7 @@ func TestHealthCheck(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockClient := &mockPrometheusClient{shouldReturnError: tt.mockError}
-			handler := newTestableGPUHandler(mockClient)
+			handler := handlers.NewGPUHandler(newMockSource(mockError(tt.mockError)))
 
 			req := httptest.NewRequest("GET", "/api/healthz", nil)
 			w := httptest.NewRecorder()
@@ -272,7 +126,7 @@ func TestGetGPUMetrics(t *testing.T) {
 			checkData:    true,
 		},
 		{
-			name:         "prometheus error",
+			name:         "source error",
 			mockError:    true,
 			expectedCode: http.StatusInternalServerError,
 			checkData:    false,
@@ -281,8 +135,7 @@ func TestGetGPUMetrics(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockClient := &mockPrometheusClient{shouldReturnError: tt.mockError}
-			handler := newTestableGPUHandler(mockClient)
+			handler := handlers.NewGPUHandler(newMockSource(mockError(tt.mockError)))
 
 			req := httptest.NewRequest("GET", "/api/v1/gpu/metrics", nil)
 			w := httptest.NewRecorder()
@@ -337,7 +190,7 @@ func TestGetGPUProcesses(t *testing.T) {
 			checkData:        true,
 		},
 		{
-			name:             "prometheus process error",
+			name:             "source process error",
 			mockProcessError: true,
 			expectedCode:     http.StatusInternalServerError,
 			checkData:        false,
@@ -346,8 +199,7 @@ func TestGetGPUProcesses(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockClient := &mockPrometheusClient{shouldProcessError: tt.mockProcessError}
-			handler := newTestableGPUHandler(mockClient)
+			handler := handlers.NewGPUHandler(newMockSource(mockError(tt.mockProcessError)))
 
 			req := httptest.NewRequest("GET", "/api/v1/gpu/processes", nil)
 			w := httptest.NewRecorder()
@@ -418,3 +270,122 @@ func TestGPUHandlerIntegration(t *testing.T) {
 	// The response will likely be an error due to no real Prometheus server
 	// but that's expected in this integration test
 }
+
+// TestHealthCheck_CircuitBreaker tests that the circuit breaker state is reported when Prometheus is failing
+func TestHealthCheck_CircuitBreaker(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer promServer.Close()
+
+	client := prometheus.NewClient(promServer.URL, prometheus.WithCircuitBreaker(prometheus.BreakerOptions{
+		Threshold: 1,
+		Cooldown:  time.Minute,
+	}))
+	handler := handlers.NewGPUHandler(client)
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Status          string                 `json:"status"`
+			CircuitBreakers []models.CircuitStatus `json:"circuit_breakers"`
+		} `json:"data"`
+	}
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.HealthCheck(w, httptest.NewRequest("GET", "/api/healthz", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+
+	if response.Success || response.Data.Status != "unhealthy" {
+		t.Errorf("expected an unhealthy response, got %+v", response)
+	}
+	if len(response.Data.CircuitBreakers) != 1 || response.Data.CircuitBreakers[0].State != models.CircuitOpen {
+		t.Errorf("expected an open circuit, got %+v", response.Data.CircuitBreakers)
+	}
+}
+
+// TestErrorResponse_RequestID tests that error responses carry the request ID for log correlation
+func TestErrorResponse_RequestID(t *testing.T) {
+	handler := handlers.NewGPUHandler(newMockSource(mockError(true)))
+	server := middleware.RequestID(http.HandlerFunc(handler.GetGPUMetrics))
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	var response models.APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusInternalServerError || response.RequestID != "req-42" {
+		t.Errorf("expected a 500 carrying request ID req-42, got %d and %q", w.Code, response.RequestID)
+	}
+}
+
+// TestGetGPUMetrics_Partial tests that a failing query yields null fields and a warning instead of a 500
+func TestGetGPUMetrics_Partial(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == prometheus.SchemaCustom.Metrics[prometheus.FieldCPUUtilization].Query {
+			http.Error(w, "target down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"hostname":"node1","gpu_id":"0"},"value":[1640995200,"42"]}]}}`))
+	}))
+	defer upstream.Close()
+
+	handler := handlers.NewGPUHandler(prometheus.NewClient(upstream.URL))
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetGPUMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Version  int              `json:"version"`
+		Data     []map[string]any `json:"data"`
+		Warnings []models.Warning `json:"warnings"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Version != models.APIVersion {
+		t.Errorf("expected response version %d, got %d", models.APIVersion, response.Version)
+	}
+	if len(response.Data) != 1 {
+		t.Fatalf("expected 1 GPU, got %d", len(response.Data))
+	}
+	if value, ok := response.Data[0]["cpu_utilization"]; !ok || value != nil {
+		t.Errorf("expected cpu_utilization null, got %v", value)
+	}
+	if response.Data[0]["gpu_utilization"] != 42.0 {
+		t.Errorf("expected gpu_utilization 42, got %v", response.Data[0]["gpu_utilization"])
+	}
+	if len(response.Warnings) != 1 || response.Warnings[0].Query != prometheus.FieldCPUUtilization {
+		t.Errorf("unexpected warnings %+v", response.Warnings)
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,121 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+func TestGetGPUMetricsHistory(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"values":[[1640995200,"75"]]}]}}`))
+	}))
+	defer promServer.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		expectedCode int
+	}{
+		{
+			name:         "default range",
+			url:          "/api/v1/gpu/metrics/history",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "explicit range with filters",
+			url:          "/api/v1/gpu/metrics/history?start=2024-01-01T00:00:00Z&end=2024-01-01T08:00:00Z&step=5m&node=node1&gpu=0",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unix timestamps and numeric step",
+			url:          "/api/v1/gpu/metrics/history?start=1704067200&end=1704070800&step=30",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid start",
+			url:          "/api/v1/gpu/metrics/history?start=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "start after end",
+			url:          "/api/v1/gpu/metrics/history?start=1704070800&end=1704067200",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "too many points",
+			url:          "/api/v1/gpu/metrics/history?start=1704067200&end=1704153600&step=1s",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "non-numeric gpu",
+			url:          "/api/v1/gpu/metrics/history?gpu=first",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	client := prometheus.NewClient(promServer.URL)
+	handler := handlers.NewGPUHandler(cache.New(client, cache.Options{}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetGPUMetricsHistory(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			var response models.APIResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if tt.expectedCode == http.StatusOK && (!response.Success || response.Data == nil) {
+				t.Errorf("expected successful response with data, got %+v", response)
+			}
+			if tt.expectedCode != http.StatusOK && (response.Success || response.Error == "") {
+				t.Errorf("expected error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// pingOnlySource implements only the base MetricsSource interface
+type pingOnlySource struct{}
+
+func (pingOnlySource) GetGPUMetrics(ctx context.Context) ([]models.GPUMetrics, error) {
+	return nil, nil
+}
+
+func (pingOnlySource) GetGPUProcesses(ctx context.Context) ([]models.GPUProcess, error) {
+	return nil, nil
+}
+
+func (pingOnlySource) Ping(ctx context.Context) error {
+	return nil
+}
+
+func TestGetGPUMetricsHistory_Unsupported(t *testing.T) {
+	handler := handlers.NewGPUHandler(pingOnlySource{})
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics/history", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetGPUMetricsHistory(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, w.Code)
+	}
+}
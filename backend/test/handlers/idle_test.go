@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+func TestGetIdleGPUs(t *testing.T) {
+	now := time.Now().Unix()
+	values := func(v string) string {
+		var points []string
+		for i := int64(12); i >= 0; i-- {
+			points = append(points, fmt.Sprintf(`[%d,"%s"]`, now-i*600, v))
+		}
+		return strings.Join(points, ",")
+	}
+
+	responses := map[string]string{
+		"gpu_metrics_utilization_percent": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"values":[` + values("0") + `]}]}}`,
+		"gpu_metrics_used_memory": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"values":[` + values("8192") + `]}]}}`,
+		"gpu_metrics_temperature": `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+		"gpu_process_gpu_memory": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","pid":"1234","user":"alice","command":"python notebook.py"},"value":[1640995200,"8192"]}]}}`,
+	}
+
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			http.Error(w, "Unknown query", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer promServer.Close()
+
+	handler := handlers.NewGPUHandler(cache.New(prometheus.NewClient(promServer.URL), cache.Options{}))
+
+	tests := []struct {
+		name         string
+		url          string
+		expectedCode int
+		expectedLen  int
+	}{
+		{"defaults", "/api/v1/gpu/idle", http.StatusOK, 1},
+		{"longer minimum", "/api/v1/gpu/idle?min_idle=3h&window=6h", http.StatusOK, 0},
+		{"invalid threshold", "/api/v1/gpu/idle?threshold=0", http.StatusBadRequest, 0},
+		{"window shorter than minimum", "/api/v1/gpu/idle?min_idle=2h&window=1h", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetIdleGPUs(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data []models.IdleGPU `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Data) != tt.expectedLen {
+				t.Fatalf("expected %d idle GPUs, got %+v", tt.expectedLen, response.Data)
+			}
+			if tt.expectedLen > 0 && (response.Data[0].IdleSeconds != 7200 || len(response.Data[0].Processes) != 1) {
+				t.Errorf("expected 2h idle GPU with alice's process, got %+v", response.Data[0])
+			}
+		})
+	}
+}
+
+func TestGetIdleGPUs_Unsupported(t *testing.T) {
+	handler := handlers.NewGPUHandler(pingOnlySource{})
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/idle", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetIdleGPUs(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, w.Code)
+	}
+}
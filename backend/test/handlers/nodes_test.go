@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+func TestGetGPUNodes(t *testing.T) {
+	handler := handlers.NewGPUHandler(newMockSource(nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/nodes", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetGPUNodes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Success bool                 `json:"success"`
+		Data    []models.NodeSummary `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.Success || len(response.Data) == 0 {
+		t.Errorf("expected node summaries, got %+v", response)
+	}
+
+	// Errors from the source are reported as 500
+	handler = handlers.NewGPUHandler(newMockSource(mockError(true)))
+	w = httptest.NewRecorder()
+	handler.GetGPUNodes(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestGetGPUUtilization(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("query"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"1","gpu_name":"NVIDIA Tesla V100"},"value":[1640995200,"50"]},
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"value":[1640995200,"75.5"]}]}}`))
+	}))
+	defer promServer.Close()
+
+	handler := handlers.NewGPUHandler(cache.New(prometheus.NewClient(promServer.URL), cache.Options{}))
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/utilization", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetGPUUtilization(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Success bool                    `json:"success"`
+		Data    []models.GPUUtilization `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Data) != 2 || response.Data[0].GPUIndex != 0 || !equalPtr(response.Data[0].GPUUtilization, ptr(75.5)) {
+		t.Errorf("unexpected utilization %+v", response.Data)
+	}
+
+	if len(queries) != 1 || queries[0] != "gpu_metrics_utilization_percent" {
+		t.Errorf("expected a single utilization query, got %v", queries)
+	}
+}
+
+func TestGetGPUUtilization_Fallback(t *testing.T) {
+	handler := handlers.NewGPUHandler(pingOnlySource{})
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/utilization", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetGPUUtilization(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/auth"
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/redact"
+	"k8s-gpu-monitoring/internal/usage"
+)
+
+// TestGetGPUProcesses_Redaction tests that process fields are redacted by the caller's groups
+func TestGetGPUProcesses_Redaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	os.WriteFile(path, []byte("admin:gpu-admin:admin-token\nviewer:viewer-token\n"), 0o600)
+	tokens, err := auth.LoadTokenFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	policy, err := redact.NewPolicy(redact.Config{
+		Default: redact.Fields{User: redact.ModeMask, Command: redact.ModeMask},
+		Rules:   []redact.Rule{{Groups: []string{"gpu-admin"}, Fields: redact.Fields{User: redact.ModeShow, Command: redact.ModeShow}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler := handlers.NewGPUHandler(newMockSource(nil), handlers.WithRedaction(policy))
+	server := auth.Middleware(tokens)(http.HandlerFunc(handler.GetGPUProcesses))
+
+	tests := []struct {
+		name            string
+		token           string
+		expectedUser    string
+		expectedCommand string
+	}{
+		{"admin", "admin-token", "user1", "python train.py"},
+		{"viewer", "viewer-token", redact.Masked, redact.Masked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/gpu/processes", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			var response struct {
+				Data []models.GPUProcess `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Data) != 1 {
+				t.Fatalf("expected 1 process, got %d", len(response.Data))
+			}
+			if got := response.Data[0]; got.User != tt.expectedUser || got.Command != tt.expectedCommand {
+				t.Errorf("expected user %q and command %q, got %q and %q", tt.expectedUser, tt.expectedCommand, got.User, got.Command)
+			}
+		})
+	}
+}
+
+// TestGetGPUUsageByUser_Redaction tests that masked users are merged into a single row
+func TestGetGPUUsageByUser_Redaction(t *testing.T) {
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","pid":"1","user":"alice"},"value":[1640995200,"4096"]},
+			{"metric":{"hostname":"node1","gpu_id":"1","pid":"2","user":"bob"},"value":[1640995200,"1024"]},
+			{"metric":{"hostname":"node1","gpu_id":"1","pid":"3"},"value":[1640995200,"512"]}]}}`))
+	}))
+	defer promServer.Close()
+
+	policy, err := redact.NewPolicy(redact.Config{Default: redact.Fields{User: redact.ModeMask}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := handlers.NewGPUHandler(prometheus.NewClient(promServer.URL), handlers.WithRedaction(policy))
+
+	w := httptest.NewRecorder()
+	handler.GetGPUUsageByUser(w, httptest.NewRequest("GET", "/api/v1/gpu/usage/by-user", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data []models.GPUUsage `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	expected := []models.GPUUsage{
+		{Name: redact.Masked, GPUMemory: 5120, GPUCount: 2, ProcessCount: 2},
+		{Name: usage.Unknown, GPUMemory: 512, GPUCount: 1, ProcessCount: 1},
+	}
+	if len(response.Data) != len(expected) || response.Data[0] != expected[0] || response.Data[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, response.Data)
+	}
+}
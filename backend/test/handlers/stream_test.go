@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/stream"
+)
+
+func TestStreamGPUMetrics(t *testing.T) {
+	fetch := func(ctx context.Context) ([]models.GPUMetrics, error) {
+		return []models.GPUMetrics{{NodeName: "node1", GPUIndex: 0, GPUUtilization: ptr(75.0)}}, nil
+	}
+
+	hub := stream.NewHub(fetch, time.Hour)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	defer stopHub()
+	go hub.Run(hubCtx)
+
+	server := httptest.NewServer(http.HandlerFunc(handlers.NewStreamHandler(hub).StreamGPUMetrics))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect to stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream content type, got %s", ct)
+	}
+
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" && data != "" {
+			break
+		}
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			event = v
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			data = v
+		}
+	}
+
+	if event != "metrics" {
+		t.Errorf("expected metrics event, got %q", event)
+	}
+
+	var response models.APIResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("failed to decode event data: %v", err)
+	}
+	if !response.Success || response.Data == nil {
+		t.Errorf("expected successful response with data, got %+v", response)
+	}
+
+	// Stopping the hub must end the stream
+	stopHub()
+	done := make(chan struct{})
+	go func() {
+		for scanner.Scan() {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("stream did not end after hub stopped")
+	}
+}
@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/middleware"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// getTimestamps requests the GPU metrics through the timezone middleware and returns the metric and process timestamps
+func getTimestamps(t *testing.T, h *handlers.GPUHandler, target string, header http.Header) (string, string) {
+	t.Helper()
+	timestamps := make([]string, 0, 2)
+	for _, get := range []http.HandlerFunc{h.GetGPUMetrics, h.GetGPUProcesses} {
+		req := httptest.NewRequest("GET", target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rr := httptest.NewRecorder()
+		middleware.Timezone(get).ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		var response struct {
+			Data []struct {
+				Timestamp string `json:"timestamp"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || len(response.Data) != 1 {
+			t.Fatalf("unexpected response %s", rr.Body.String())
+		}
+		timestamps = append(timestamps, response.Data[0].Timestamp)
+	}
+	return timestamps[0], timestamps[1]
+}
+
+// TestTimezone tests that timestamps follow the requested time zone and the server format
+func TestTimezone(t *testing.T) {
+	src := newMockSource(nil)
+	src.Metrics[0].Timestamp = "2024-01-01T03:00:00Z"
+	h := handlers.NewGPUHandler(src)
+
+	defer timeutil.SetDefault(timeutil.Default())
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	tests := []struct {
+		name          string
+		target        string
+		header        http.Header
+		legacy        bool
+		wantMetrics   string
+		wantProcesses string
+	}{
+		{"server default", "/api/v1/gpu/metrics", nil, false, "2024-01-01T12:00:00+09:00", "2024-01-01T12:00:00+09:00"},
+		{"query parameter", "/api/v1/gpu/metrics?tz=America/New_York", nil, false, "2023-12-31T22:00:00-05:00", "2023-12-31T22:00:00-05:00"},
+		{"header", "/api/v1/gpu/metrics", http.Header{middleware.TimezoneHeader: {"UTC"}}, false, "2024-01-01T03:00:00Z", "2024-01-01T03:00:00Z"},
+		{"query parameter wins", "/api/v1/gpu/metrics?tz=UTC", http.Header{middleware.TimezoneHeader: {"America/New_York"}}, false, "2024-01-01T03:00:00Z", "2024-01-01T03:00:00Z"},
+		{"legacy format", "/api/v1/gpu/metrics?tz=Europe/London", nil, true, "2024/01/01 03:00:00", "2024/01/01 03:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeutil.SetDefault(timeutil.Format{Location: tokyo, Legacy: tt.legacy})
+			metrics, processes := getTimestamps(t, h, tt.target, tt.header)
+			if metrics != tt.wantMetrics || processes != tt.wantProcesses {
+				t.Errorf("expected %s and %s, got %s and %s", tt.wantMetrics, tt.wantProcesses, metrics, processes)
+			}
+		})
+	}
+
+	// Responses are converted on copies, leaving the source data alone
+	if src.Metrics[0].Timestamp != "2024-01-01T03:00:00Z" {
+		t.Errorf("expected the source data to be unchanged, got %s", src.Metrics[0].Timestamp)
+	}
+}
+
+// TestTimezone_Unknown tests that unknown time zones are rejected
+func TestTimezone_Unknown(t *testing.T) {
+	h := handlers.NewGPUHandler(newMockSource(nil))
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics?tz=Mars/Olympus_Mons", nil)
+	rr := httptest.NewRecorder()
+	middleware.Timezone(http.HandlerFunc(h.GetGPUMetrics)).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rr.Code)
+	}
+	var response models.APIResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.Success || response.Error == "" {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/handlers"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+func TestGetGPUUsage(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("query"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","pid":"1","user":"alice","namespace":"ml"},"value":[1640995200,"4096"]},
+			{"metric":{"hostname":"node1","gpu_id":"1","pid":"2","user":"bob","namespace":"ml"},"value":[1640995200,"1024"]}]}}`))
+	}))
+	defer promServer.Close()
+
+	handler := handlers.NewGPUHandler(cache.New(prometheus.NewClient(promServer.URL), cache.Options{}))
+
+	tests := []struct {
+		name         string
+		url          string
+		handle       http.HandlerFunc
+		expectedCode int
+		expectedLen  int
+		windowed     bool
+	}{
+		{"by user", "/api/v1/gpu/usage/by-user", handler.GetGPUUsageByUser, http.StatusOK, 2, false},
+		{"by namespace", "/api/v1/gpu/usage/by-namespace", handler.GetGPUUsageByNamespace, http.StatusOK, 1, false},
+		{"by user over window", "/api/v1/gpu/usage/by-user?window=1h", handler.GetGPUUsageByUser, http.StatusOK, 2, true},
+		{"invalid window", "/api/v1/gpu/usage/by-user?window=forever", handler.GetGPUUsageByUser, http.StatusBadRequest, 0, false},
+		{"window too large", "/api/v1/gpu/usage/by-namespace?window=720h", handler.GetGPUUsageByNamespace, http.StatusBadRequest, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			queries = nil
+			mu.Unlock()
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			tt.handle(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Success bool              `json:"success"`
+				Data    []models.GPUUsage `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !response.Success || len(response.Data) != tt.expectedLen {
+				t.Errorf("expected %d entries, got %+v", tt.expectedLen, response)
+			}
+
+			if tt.windowed {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(queries) == 0 || !strings.HasPrefix(queries[0], "max_over_time((") || !strings.HasSuffix(queries[0], ")[3600s:])") {
+					t.Errorf("expected max_over_time subquery, got %v", queries)
+				}
+			}
+		})
+	}
+}
+
+func TestGetGPUUsage_WindowUnsupported(t *testing.T) {
+	handler := handlers.NewGPUHandler(pingOnlySource{})
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/usage/by-user?window=1h", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetGPUUsageByUser(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d, got %d", http.StatusNotImplemented, w.Code)
+	}
+}
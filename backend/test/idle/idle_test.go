@@ -0,0 +1,78 @@
+package idle_test
+
+import (
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/idle"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/timeutil"
+)
+
+// series builds a GPU series with one sample every 10 minutes from (utilization, memory) pairs
+func series(node string, gpu int, samples ...[2]int) models.GPUMetricsSeries {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := models.GPUMetricsSeries{NodeName: node, GPUIndex: gpu, GPUName: "NVIDIA Tesla V100"}
+	for i, sample := range samples {
+		s.Samples = append(s.Samples, models.GPUMetricsSample{
+			Timestamp:      timeutil.FormatRFC3339(start.Add(time.Duration(i) * 10 * time.Minute)),
+			GPUUtilization: ptr(float64(sample[0])),
+			GPUMemoryUsed:  ptr(sample[1]),
+		})
+	}
+	return s
+}
+
+// TestAnalyze tests measuring the trailing idle period and attaching the owning processes
+func TestAnalyze(t *testing.T) {
+	history := []models.GPUMetricsSeries{
+		// Busy, then idle with memory held for 50 minutes
+		series("node1", 0, [2]int{90, 8000}, [2]int{2, 8000}, [2]int{0, 8000}, [2]int{1, 8000}, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{3, 8000}),
+		// Idle for 20 minutes only
+		series("node1", 1, [2]int{90, 8000}, [2]int{90, 8000}, [2]int{90, 8000}, [2]int{90, 8000}, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{0, 8000}),
+		// Idle but no memory allocated
+		series("node2", 0, [2]int{0, 0}, [2]int{0, 0}, [2]int{0, 0}, [2]int{0, 0}, [2]int{0, 0}, [2]int{0, 0}, [2]int{0, 0}),
+		// Busy now
+		series("node2", 1, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{0, 8000}, [2]int{50, 8000}),
+	}
+	processes := []models.GPUProcess{
+		{NodeName: "node1", GPUIndex: 0, PID: 1234, User: "alice", Command: "python notebook.py", GPUMemory: 8000},
+		{NodeName: "node1", GPUIndex: 1, PID: 5678, User: "bob"},
+	}
+
+	idleGPUs, err := idle.Analyze(history, processes, idle.Options{Threshold: 5, MinIdle: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(idleGPUs) != 1 {
+		t.Fatalf("expected 1 idle GPU, got %+v", idleGPUs)
+	}
+
+	got := idleGPUs[0]
+	if got.NodeName != "node1" || got.GPUIndex != 0 {
+		t.Errorf("expected node1 GPU 0, got %s GPU %d", got.NodeName, got.GPUIndex)
+	}
+	if got.IdleSeconds != 3000 {
+		t.Errorf("expected 3000 idle seconds, got %d", got.IdleSeconds)
+	}
+	if got.MaxUtilization != 3 {
+		t.Errorf("expected max utilization 3, got %v", got.MaxUtilization)
+	}
+	if got.IdleSince != history[0].Samples[1].Timestamp {
+		t.Errorf("expected idle since %s, got %s", history[0].Samples[1].Timestamp, got.IdleSince)
+	}
+	if len(got.Processes) != 1 || got.Processes[0].User != "alice" || got.Processes[0].PID != 1234 {
+		t.Errorf("expected alice's process, got %+v", got.Processes)
+	}
+
+	// Lowering the minimum reports the shorter idle period as well, longest first
+	idleGPUs, _ = idle.Analyze(history, processes, idle.Options{Threshold: 5, MinIdle: 10 * time.Minute})
+	if len(idleGPUs) != 2 || idleGPUs[1].GPUIndex != 1 || idleGPUs[1].IdleSeconds != 1200 {
+		t.Errorf("expected node1 GPU 1 idle for 1200s second, got %+v", idleGPUs)
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
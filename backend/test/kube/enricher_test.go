@@ -0,0 +1,125 @@
+package kube_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/kube"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+// newKubeStateMetrics starts a Prometheus stand-in serving kube-state-metrics series
+func newKubeStateMetrics(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	responses := map[string]string{
+		`kube_pod_container_info{container_id!=""}`: `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"namespace":"ml","pod":"trainer-7d9f-abcde","container":"trainer","container_id":"containerd://0123456789abcdef0123456789abcdef"},"value":[1640995200,"1"]},
+			{"metric":{"namespace":"batch","pod":"nightly-28400-xyz","container":"job","container_id":"containerd://fedcba9876543210fedcba9876543210"},"value":[1640995200,"1"]}]}}`,
+		`kube_pod_info`: `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"namespace":"ml","pod":"trainer-7d9f-abcde","created_by_kind":"ReplicaSet","created_by_name":"trainer-7d9f"},"value":[1640995200,"1"]},
+			{"metric":{"namespace":"batch","pod":"nightly-28400-xyz","created_by_kind":"Job","created_by_name":"nightly-28400"},"value":[1640995200,"1"]},
+			{"metric":{"namespace":"dev","pod":"notebook","created_by_kind":"<none>","created_by_name":"<none>"},"value":[1640995200,"1"]}]}}`,
+		`kube_replicaset_owner`: `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"namespace":"ml","replicaset":"trainer-7d9f","owner_kind":"Deployment","owner_name":"trainer"},"value":[1640995200,"1"]}]}}`,
+		`kube_job_owner`: `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"namespace":"batch","job_name":"nightly-28400","owner_kind":"CronJob","owner_name":"nightly"},"value":[1640995200,"1"]}]}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			http.Error(w, "Unknown query", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestEnricher_GetGPUProcesses tests joining processes with pods and resolving owning workloads
+func TestEnricher_GetGPUProcesses(t *testing.T) {
+	server := newKubeStateMetrics(t)
+
+	upstream := source.NewFixture(nil, []models.GPUProcess{
+		{NodeName: "node1", PID: 1, ContainerID: "containerd://0123456789abcdef0123456789abcdef"},
+		{NodeName: "node1", PID: 2, ContainerID: "fedcba987654"},
+		{NodeName: "node1", PID: 3, Namespace: "dev", Pod: "notebook"},
+		{NodeName: "node1", PID: 4},
+	})
+	enricher := kube.NewEnricher(upstream, prometheus.NewClient(server.URL))
+
+	processes, err := enricher.GetGPUProcesses(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(processes) != 4 {
+		t.Fatalf("expected 4 processes, got %d", len(processes))
+	}
+
+	tests := []struct {
+		name         string
+		namespace    string
+		pod          string
+		container    string
+		workloadKind string
+		workloadName string
+	}{
+		{"deployment via full container ID", "ml", "trainer-7d9f-abcde", "trainer", "Deployment", "trainer"},
+		{"cronjob via short container ID", "batch", "nightly-28400-xyz", "job", "CronJob", "nightly"},
+		{"bare pod from labels", "dev", "notebook", "", "", ""},
+		{"host process", "", "", "", "", ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := processes[i]
+			if p.Namespace != tt.namespace || p.Pod != tt.pod || p.Container != tt.container {
+				t.Errorf("expected %s/%s/%s, got %s/%s/%s", tt.namespace, tt.pod, tt.container, p.Namespace, p.Pod, p.Container)
+			}
+			if p.WorkloadKind != tt.workloadKind || p.WorkloadName != tt.workloadName {
+				t.Errorf("expected workload %s/%s, got %s/%s", tt.workloadKind, tt.workloadName, p.WorkloadKind, p.WorkloadName)
+			}
+		})
+	}
+
+	// The upstream data must not be modified in place
+	original, _ := upstream.GetGPUProcesses(context.Background())
+	if original[0].Namespace != "" {
+		t.Error("expected upstream processes to be left untouched")
+	}
+}
+
+// TestEnricher_LookupFailure tests that processes are returned unenriched when kube-state-metrics is unavailable
+func TestEnricher_LookupFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	upstream := source.NewFixture(nil, []models.GPUProcess{
+		{NodeName: "node1", PID: 1, ContainerID: "containerd://0123456789abcdef0123456789abcdef"},
+	})
+	enricher := kube.NewEnricher(upstream, prometheus.NewClient(server.URL))
+
+	processes, err := enricher.GetGPUProcesses(context.Background())
+	if err != nil {
+		t.Fatalf("expected lookup failure to be tolerated, got %v", err)
+	}
+	if len(processes) != 1 || processes[0].Pod != "" {
+		t.Errorf("expected unenriched process, got %+v", processes)
+	}
+
+	// Upstream errors are still propagated
+	upstream.Err = errors.New("connection refused")
+	if _, err := enricher.GetGPUProcesses(context.Background()); err == nil {
+		t.Error("expected upstream error")
+	}
+}
@@ -0,0 +1,103 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/middleware"
+)
+
+// TestCORS tests origin allowlisting for simple and preflight requests
+func TestCORS(t *testing.T) {
+	cors, err := middleware.NewCORS(middleware.CORSOptions{
+		AllowedOrigins:   []string{"https://dashboard.example.com", "https://*.gpu.example.com", "http://localhost:5173"},
+		AllowCredentials: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var reached bool
+	handler := cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		requestMethod   string
+		requestHeaders  string
+		expectedCode    int
+		expectedOrigin  string
+		expectedReached bool
+	}{
+		{"same origin", "GET", "", "", "", http.StatusOK, "", true},
+		{"exact origin", "GET", "https://dashboard.example.com", "", "", http.StatusOK, "https://dashboard.example.com", true},
+		{"subdomain", "GET", "https://team-a.gpu.example.com", "", "", http.StatusOK, "https://team-a.gpu.example.com", true},
+		{"wildcard parent itself", "GET", "https://gpu.example.com", "", "", http.StatusOK, "", true},
+		{"lookalike domain", "GET", "https://evilgpu.example.com", "", "", http.StatusOK, "", true},
+		{"scheme mismatch", "GET", "http://dashboard.example.com", "", "", http.StatusOK, "", true},
+		{"port mismatch", "GET", "http://localhost:3000", "", "", http.StatusOK, "", true},
+		{"unknown origin", "GET", "https://evil.example.org", "", "", http.StatusOK, "", true},
+		{"preflight", "OPTIONS", "http://localhost:5173", "GET", "authorization, x-request-id", http.StatusNoContent, "http://localhost:5173", false},
+		{"preflight from unknown origin", "OPTIONS", "https://evil.example.org", "GET", "", http.StatusForbidden, "", false},
+		{"preflight with disallowed method", "OPTIONS", "https://dashboard.example.com", "DELETE", "", http.StatusForbidden, "https://dashboard.example.com", false},
+		{"preflight with disallowed header", "OPTIONS", "https://dashboard.example.com", "GET", "X-Custom", http.StatusForbidden, "https://dashboard.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = false
+			req := httptest.NewRequest(tt.method, "/api/v1/gpu/metrics", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.expectedOrigin {
+				t.Errorf("expected allowed origin %q, got %q", tt.expectedOrigin, got)
+			}
+			if reached != tt.expectedReached {
+				t.Errorf("expected handler reached %t, got %t", tt.expectedReached, reached)
+			}
+			if tt.expectedOrigin != "" && w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("expected credentials to be allowed")
+			}
+		})
+	}
+}
+
+// TestNewCORS_Invalid tests configuration validation
+func TestNewCORS_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts middleware.CORSOptions
+	}{
+		{"missing scheme", middleware.CORSOptions{AllowedOrigins: []string{"dashboard.example.com"}}},
+		{"path", middleware.CORSOptions{AllowedOrigins: []string{"https://example.com/app"}}},
+		{"inner wildcard", middleware.CORSOptions{AllowedOrigins: []string{"https://gpu.*.example.com"}}},
+		{"wildcard with credentials", middleware.CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := middleware.NewCORS(tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/logging"
+	"k8s-gpu-monitoring/internal/middleware"
+)
+
+// TestRequestID tests that request IDs are propagated or generated and reach the context
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name      string
+		header    string
+		propagate bool
+	}{
+		{"generated", "", false},
+		{"propagated", "abc-123", true},
+		{"control characters", "abc\x01def", false},
+		{"too long", strings.Repeat("a", 200), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/gpu/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			got := w.Header().Get(middleware.RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("expected response and context IDs to match, got %q and %q", got, seen)
+			}
+			if tt.propagate && got != tt.header {
+				t.Errorf("expected %q to be propagated, got %q", tt.header, got)
+			}
+			if !tt.propagate && len(got) != 32 {
+				t.Errorf("expected a generated 32 character ID, got %q", got)
+			}
+		})
+	}
+}
+
+// TestLogger tests that request log lines are structured and carry the request ID
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	handler := middleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}), middleware.RequestID, middleware.Logger)
+
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if line["request_id"] != "req-42" || line["path"] != "/api/v1/gpu/metrics" || line["status"] != float64(http.StatusTeapot) {
+		t.Errorf("unexpected log line %v", line)
+	}
+}
+
+// TestLoggingNew_Invalid tests that unknown levels and formats are rejected
+func TestLoggingNew_Invalid(t *testing.T) {
+	if _, err := logging.New(&bytes.Buffer{}, "verbose", "json"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if _, err := logging.New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
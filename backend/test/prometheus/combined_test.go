@@ -0,0 +1,177 @@
+package prometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/report"
+)
+
+// combinedSeries answers a combined custom schema query, one series per metric
+const combinedSeries = `{"status":"success","data":{"resultType":"vector","result":[
+	{"metric":{"__name__":"gpu_metrics_used_memory","hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA A100"},"value":[1640995200,"1024"]},
+	{"metric":{"__name__":"gpu_metrics_total_memory","hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA A100"},"value":[1640995200,"4096"]},
+	{"metric":{"__name__":"gpu_metrics_utilization_percent","hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA A100"},"value":[1640995200,"87.5"]},
+	{"metric":{"__name__":"gpu_metrics_cpu_utilization","hostname":"node1"},"value":[1640995200,"12"]}]}}`
+
+// recordQueries returns a handler recording each query and answering it with respond
+func recordQueries(mu *sync.Mutex, queries *[]string, respond func(w http.ResponseWriter, query string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		*queries = append(*queries, query)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		respond(w, query)
+	}
+}
+
+// valueQueries drops the queries reading sample times from queries
+func valueQueries(queries []string) []string {
+	var values []string
+	for _, query := range queries {
+		if !strings.HasPrefix(query, "timestamp(") {
+			values = append(values, query)
+		}
+	}
+	return values
+}
+
+// TestPrometheusClient_GetGPUMetrics_Combined tests that plain metrics are read in one query and split by metric name
+func TestPrometheusClient_GetGPUMetrics_Combined(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(recordQueries(&mu, &queries, func(w http.ResponseWriter, query string) {
+		w.Write([]byte(combinedSeries))
+	}))
+	defer server.Close()
+
+	metrics, err := prometheus.NewClient(server.URL).GetGPUMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values := valueQueries(queries)
+	if len(values) != 1 || !strings.HasPrefix(values[0], `{__name__=~"`) || !strings.Contains(values[0], "gpu_metrics_temperature") {
+		t.Fatalf("expected a single combined query, got %q", queries)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+
+	m := metrics[0]
+	if !equalPtr(m.GPUMemoryUsed, ptr(1024)) || !equalPtr(m.GPUMemoryTotal, ptr(4096)) || !equalPtr(m.GPUUtilization, ptr(87.5)) {
+		t.Errorf("unexpected GPU values %+v", m)
+	}
+	if !equalPtr(m.CPUUtilization, ptr(12.0)) {
+		t.Errorf("expected node CPU utilization 12, got %v", m.CPUUtilization)
+	}
+	if m.GPUTemperature != nil || m.GPUMemoryFree != nil {
+		t.Errorf("expected metrics without series to be nil, got temperature %v free %v", m.GPUTemperature, m.GPUMemoryFree)
+	}
+}
+
+// TestPrometheusClient_GetGPUMetrics_CombinedRejected tests the fallback to one query per field
+func TestPrometheusClient_GetGPUMetrics_CombinedRejected(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(recordQueries(&mu, &queries, func(w http.ResponseWriter, query string) {
+		if strings.Contains(query, "__name__") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"__name__ matchers are not allowed"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":` + customLabels + `,"value":[1640995200,"42"]}]}}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL)
+	metrics, err := client.GetGPUMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || !equalPtr(metrics[0].GPUUtilization, ptr(42.0)) {
+		t.Fatalf("expected metrics from per-field queries, got %+v", metrics)
+	}
+	if got := len(valueQueries(queries)); got != 8 {
+		t.Errorf("expected the combined query and 7 per-field queries, got %d", got)
+	}
+
+	// The combined form is not tried again
+	queries = nil
+	if _, err := client.GetGPUMetrics(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, query := range queries {
+		if strings.Contains(query, "__name__") {
+			t.Errorf("expected no further combined queries, got %q", query)
+		}
+	}
+}
+
+// TestPrometheusClient_GetGPUMetrics_CombinedUnavailable tests that an upstream failure of the combined query
+// fails its fields without querying them one by one, and keeps the combined form for later requests
+func TestPrometheusClient_GetGPUMetrics_CombinedUnavailable(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(recordQueries(&mu, &queries, func(w http.ResponseWriter, query string) {
+		if strings.Contains(query, "__name__") {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":` + customLabels + `,"value":[1640995200,"42"]}]}}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, prometheus.WithRetry(fastRetry))
+	ctx, rep := report.NewContext(context.Background())
+	if _, err := client.GetGPUMetrics(ctx); err == nil {
+		t.Fatal("expected an error when every GPU field failed")
+	}
+	for _, query := range valueQueries(queries) {
+		if !strings.Contains(query, "__name__") {
+			t.Errorf("expected no per-field queries, got %q", query)
+		}
+	}
+	if got := len(rep.Warnings()); got != 7 {
+		t.Errorf("expected a warning per combined field, got %d", got)
+	}
+
+	// The combined form stays enabled, unlike after a rejection
+	queries = nil
+	client.GetGPUMetrics(context.Background())
+	if values := valueQueries(queries); len(values) == 0 || !strings.Contains(values[0], "__name__") {
+		t.Errorf("expected the combined query to be tried again, got %q", queries)
+	}
+}
+
+// TestPrometheusClient_GetGPUMetrics_CombinedExpressions tests that expressions other than plain metric names are queried separately
+func TestPrometheusClient_GetGPUMetrics_CombinedExpressions(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(recordQueries(&mu, &queries, func(w http.ResponseWriter, query string) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	// DCGM converts memory with arithmetic, leaving utilization and temperature as plain metrics
+	prometheus.NewClient(server.URL, prometheus.WithSchema(prometheus.SchemaDCGM)).GetGPUMetrics(context.Background())
+
+	combined := 0
+	for _, query := range queries {
+		if strings.Contains(query, "__name__") {
+			combined++
+			if strings.Contains(query, "*") {
+				t.Errorf("expected expressions to be left out of the combined query, got %q", query)
+			}
+		}
+	}
+	if combined != 1 {
+		t.Errorf("expected 1 combined query, got %d in %q", combined, queries)
+	}
+}
@@ -0,0 +1,195 @@
+package prometheus_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/report"
+)
+
+// combinedNames extracts the metric names of a combined {__name__=~"..."} query
+var combinedNames = regexp.MustCompile(`__name__=~"([^"]+)"`)
+
+// newSampleTimeServer answers queries for both GPUs of node1, with the sample times in times for the GPUs
+// it has. Combined queries carry them as marked series, and timestamp() queries fail with 503 when times is nil.
+func newSampleTimeServer(t *testing.T, times map[string]time.Time) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(recordQueries(&mu, &queries, func(w http.ResponseWriter, query string) {
+		evaluatedAt := time.Now().Unix()
+		var series []string
+		for _, gpu := range []string{"0", "1"} {
+			labels := fmt.Sprintf(`"hostname":"node1","gpu_id":"%s","pid":"100%s"`, gpu, gpu)
+			sampleTime := fmt.Sprintf(`{"metric":{%s},"value":[%d,"%d"]}`, labels, evaluatedAt, times[gpu].Unix())
+			switch {
+			case strings.HasPrefix(query, "timestamp("):
+				if times == nil {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				if _, ok := times[gpu]; ok {
+					series = append(series, sampleTime)
+				}
+			case strings.HasPrefix(query, "{__name__"):
+				for _, name := range strings.Split(combinedNames.FindStringSubmatch(query)[1], "|") {
+					series = append(series, fmt.Sprintf(`{"metric":{"__name__":%q,%s},"value":[%d,"42"]}`, name, labels, evaluatedAt))
+				}
+				if _, ok := times[gpu]; ok && strings.Contains(query, "timestamp(") {
+					series = append(series, fmt.Sprintf(`{"metric":{"gpu_monitoring_sample_time":"true",%s},"value":[%d,"%d"]}`, labels, evaluatedAt, times[gpu].Unix()))
+				}
+			default:
+				series = append(series, fmt.Sprintf(`{"metric":{%s},"value":[%d,"42"]}`, labels, evaluatedAt))
+			}
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, strings.Join(series, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+// TestPrometheusClient_SampleTimes tests that rows are dated by their last sample and old ones flagged stale
+func TestPrometheusClient_SampleTimes(t *testing.T) {
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fresh := time.Now().Add(-10 * time.Second).Truncate(time.Second)
+	server, queries := newSampleTimeServer(t, map[string]time.Time{"0": old, "1": fresh})
+	client := prometheus.NewClient(server.URL, prometheus.WithStaleThreshold(5*time.Minute))
+
+	metrics, err := client.GetGPUMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+	for _, m := range metrics {
+		want := map[int]time.Time{0: old, 1: fresh}[m.GPUIndex]
+		got, err := time.Parse(time.RFC3339, m.Timestamp)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("GPU %d: expected timestamp %v, got %q", m.GPUIndex, want, m.Timestamp)
+		}
+		if m.Stale != (m.GPUIndex == 0) {
+			t.Errorf("GPU %d: unexpected stale flag %v", m.GPUIndex, m.Stale)
+		}
+	}
+	// The sample times are read by the combined query
+	if got := queries(); len(got) != 1 || !strings.Contains(got[0], "timestamp(gpu_metrics_utilization_percent)") {
+		t.Errorf("expected a single combined query reading the sample times, got %q", got)
+	}
+
+	processes, err := client.GetGPUProcesses(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(processes) != 2 || !processes[0].Stale || processes[1].Stale {
+		t.Errorf("expected only the process on GPU 0 to be stale, got %+v", processes)
+	}
+}
+
+// TestPrometheusClient_SampleTimesMissing tests that rows without a sample time are flagged stale
+func TestPrometheusClient_SampleTimesMissing(t *testing.T) {
+	fresh := time.Now().Add(-10 * time.Second).Truncate(time.Second)
+	server, _ := newSampleTimeServer(t, map[string]time.Time{"0": fresh})
+	client := prometheus.NewClient(server.URL, prometheus.WithStaleThreshold(5*time.Minute))
+
+	metrics, err := client.GetGPUMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+	for _, m := range metrics {
+		if m.Stale != (m.GPUIndex == 1) {
+			t.Errorf("GPU %d: unexpected stale flag %v", m.GPUIndex, m.Stale)
+		}
+		if _, err := time.Parse(time.RFC3339, m.Timestamp); err != nil {
+			t.Errorf("GPU %d: expected an RFC 3339 timestamp, got %q", m.GPUIndex, m.Timestamp)
+		}
+	}
+}
+
+// TestPrometheusClient_SampleTimesUnavailable tests that rows are flagged stale with a warning when their
+// sample times cannot be read
+func TestPrometheusClient_SampleTimesUnavailable(t *testing.T) {
+	server, _ := newSampleTimeServer(t, nil)
+	client := prometheus.NewClient(server.URL, prometheus.WithStaleThreshold(time.Minute))
+
+	ctx, rep := report.NewContext(context.Background())
+	processes, err := client.GetGPUProcesses(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(processes) != 2 {
+		t.Fatalf("expected 2 processes, got %d", len(processes))
+	}
+	for _, p := range processes {
+		if !p.Stale || p.GPUMemory != 42 {
+			t.Errorf("expected a stale process with its value, got %+v", p)
+		}
+		if _, err := time.Parse(time.RFC3339, p.Timestamp); err != nil {
+			t.Errorf("expected an RFC 3339 timestamp, got %q", p.Timestamp)
+		}
+	}
+
+	warnings := rep.Warnings()
+	if len(warnings) != 1 || warnings[0].Query != "sample_time" {
+		t.Errorf("expected a sample_time warning, got %+v", warnings)
+	}
+
+	// Window aggregates are not dated, so they are never flagged
+	processes, err = client.GetGPUProcessesOverWindow(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range processes {
+		if p.Stale {
+			t.Errorf("expected processes over a window not to be stale, got %+v", p)
+		}
+	}
+}
+
+// TestPrometheusClient_SampleTimesCancelled tests that a failed process query cancels the pending sample time
+// query before returning
+func TestPrometheusClient_SampleTimesCancelled(t *testing.T) {
+	cancelled := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Query().Get("query"), "timestamp(") {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			cancelled <- true
+		case <-time.After(5 * time.Second):
+			cancelled <- false
+		}
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, prometheus.WithStaleThreshold(time.Minute))
+	if _, err := client.GetGPUProcesses(context.Background()); err == nil {
+		t.Fatal("expected an error for the failed process query")
+	}
+
+	select {
+	case ok := <-cancelled:
+		if !ok {
+			t.Error("expected the sample time query to be cancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expected the sample time query to end once the call returned")
+	}
+}
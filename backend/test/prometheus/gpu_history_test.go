@@ -0,0 +1,149 @@
+package prometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/report"
+)
+
+// TestPrometheusClient_GetGPUMetricsHistory tests parsing of range query matrices into per-GPU series
+func TestPrometheusClient_GetGPUMetricsHistory(t *testing.T) {
+	responses := map[string]string{
+		"gpu_metrics_utilization_percent": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"values":[[1640995260,"80"],[1640995200,"75"]]},
+			{"metric":{"hostname":"node1","gpu_id":"1","gpu_name":"NVIDIA Tesla V100"},"values":[[1640995200,"10"]]}]}}`,
+		"gpu_metrics_used_memory": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"values":[[1640995200,"8192"],[1640995260,"9000"]]}]}}`,
+		"gpu_metrics_temperature": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"},"values":[[1640995200,"65"],[1640995260,"invalid"]]}]}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			http.Error(w, "unexpected path", http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("start") != "1640995200" || r.URL.Query().Get("step") != "60" {
+			http.Error(w, "unexpected range", http.StatusBadRequest)
+			return
+		}
+		body, ok := responses[r.URL.Query().Get("query")]
+		if !ok {
+			http.Error(w, "Unknown query", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL)
+	ctx, rep := report.NewContext(context.Background())
+	history, err := client.GetGPUMetricsHistory(ctx, models.MetricsQuery{
+		StartTime: "1640995200",
+		EndTime:   "1640995260",
+		Step:      "60",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(history))
+	}
+
+	first := history[0]
+	if first.NodeName != "node1" || first.GPUIndex != 0 || first.GPUName != "NVIDIA Tesla V100" {
+		t.Errorf("unexpected series identity: %+v", first)
+	}
+	if len(first.Samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(first.Samples))
+	}
+
+	// Samples must be ordered by time and merged across metrics
+	if !equalPtr(first.Samples[0].GPUUtilization, ptr(75.0)) || !equalPtr(first.Samples[0].GPUMemoryUsed, ptr(8192)) || !equalPtr(first.Samples[0].GPUTemperature, ptr(65.0)) {
+		t.Errorf("unexpected first sample: %+v", first.Samples[0])
+	}
+	// The temperature series has no point at the second timestamp
+	if !equalPtr(first.Samples[1].GPUUtilization, ptr(80.0)) || !equalPtr(first.Samples[1].GPUMemoryUsed, ptr(9000)) || first.Samples[1].GPUTemperature != nil {
+		t.Errorf("unexpected second sample: %+v", first.Samples[1])
+	}
+	if first.Samples[0].Timestamp == "" {
+		t.Error("timestamp should be set for samples")
+	}
+
+	if history[1].GPUIndex != 1 || len(history[1].Samples) != 1 {
+		t.Errorf("unexpected second series: %+v", history[1])
+	}
+
+	// The unparsable temperature sample is reported rather than dropped silently
+	warnings := rep.Warnings()
+	if len(warnings) != 1 || warnings[0].Query != prometheus.FieldGPUTemperature || !strings.Contains(warnings[0].Message, "node1") {
+		t.Errorf("expected a warning for the invalid temperature sample, got %+v", warnings)
+	}
+}
+
+// TestPrometheusClient_GetGPUMetricsHistory_Filters tests that node and GPU filters become label matchers
+func TestPrometheusClient_GetGPUMetricsHistory_Filters(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("query"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL)
+	_, err := client.GetGPUMetricsHistory(context.Background(), models.MetricsQuery{
+		StartTime: "1640995200",
+		EndTime:   "1640995260",
+		Step:      "60",
+		Node:      "node1",
+		GPU:       "3",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(queries) != 3 {
+		t.Fatalf("expected 3 range queries, got %d", len(queries))
+	}
+	for _, q := range queries {
+		if !strings.HasSuffix(q, `{hostname="node1",gpu_id="3"}`) {
+			t.Errorf("expected node and gpu matchers in query, got %s", q)
+		}
+	}
+}
+
+// TestPrometheusClient_QueryRange_Error tests that Prometheus errors are surfaced
+func TestPrometheusClient_QueryRange_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"exceeded maximum resolution"}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL)
+	_, err := client.QueryRange(context.Background(), models.MetricsQuery{
+		Query:     "up",
+		StartTime: "1640995200",
+		EndTime:   "1640995260",
+		Step:      "1",
+	})
+	if err == nil {
+		t.Error("expected error for failed range query")
+	}
+}
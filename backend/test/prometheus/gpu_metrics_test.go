@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"k8s-gpu-monitoring/internal/models"
 	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/report"
 )
 
 // TestPrometheusClient_GetGPUMetrics tests the actual parsing of Prometheus responses into GPUMetrics
@@ -124,7 +126,7 @@ func TestPrometheusClient_GetGPUMetrics(t *testing.T) {
 						},
 					},
 				},
-				"gpu_metrics_temperature_celsius": {
+				"gpu_metrics_temperature": {
 					Status: "success",
 					Data: struct {
 						ResultType string `json:"resultType"`
@@ -201,13 +203,13 @@ func TestPrometheusClient_GetGPUMetrics(t *testing.T) {
 					NodeName:          "node1",
 					GPUIndex:          0,
 					GPUName:           "NVIDIA Tesla V100",
-					GPUMemoryFree:     8192,
-					GPUMemoryUsed:     8192,
-					GPUMemoryTotal:    16384,
-					GPUUtilization:    75,
-					GPUTemperature:    65,
-					CPUUtilization:    25,
-					MemoryUtilization: 50,
+					GPUMemoryFree:     ptr(8192),
+					GPUMemoryUsed:     ptr(8192),
+					GPUMemoryTotal:    ptr(16384),
+					GPUUtilization:    ptr(75.0),
+					GPUTemperature:    ptr(65.0),
+					CPUUtilization:    ptr(25.5),
+					MemoryUtilization: ptr(50.0),
 				},
 			},
 			expectError: false,
@@ -347,7 +349,7 @@ func TestPrometheusClient_GetGPUMetrics(t *testing.T) {
 						},
 					},
 				},
-				"gpu_metrics_temperature_celsius": {
+				"gpu_metrics_temperature": {
 					Status: "success",
 					Data: struct {
 						ResultType string `json:"resultType"`
@@ -444,25 +446,25 @@ func TestPrometheusClient_GetGPUMetrics(t *testing.T) {
 					NodeName:          "node1",
 					GPUIndex:          0,
 					GPUName:           "NVIDIA Tesla V100",
-					GPUMemoryFree:     4096,
-					GPUMemoryUsed:     12288,
-					GPUMemoryTotal:    16384,
-					GPUUtilization:    85,
-					GPUTemperature:    70,
-					CPUUtilization:    30,
-					MemoryUtilization: 75,
+					GPUMemoryFree:     ptr(4096),
+					GPUMemoryUsed:     ptr(12288),
+					GPUMemoryTotal:    ptr(16384),
+					GPUUtilization:    ptr(85.0),
+					GPUTemperature:    ptr(70.0),
+					CPUUtilization:    ptr(30.5),
+					MemoryUtilization: ptr(75.0),
 				},
 				{
 					NodeName:          "node2",
 					GPUIndex:          1,
 					GPUName:           "NVIDIA Tesla A100",
-					GPUMemoryFree:     8192,
-					GPUMemoryUsed:     32768,
-					GPUMemoryTotal:    40960,
-					GPUUtilization:    95,
-					GPUTemperature:    80,
-					CPUUtilization:    45,
-					MemoryUtilization: 80,
+					GPUMemoryFree:     ptr(8192),
+					GPUMemoryUsed:     ptr(32768),
+					GPUMemoryTotal:    ptr(40960),
+					GPUUtilization:    ptr(95.0),
+					GPUTemperature:    ptr(80.0),
+					CPUUtilization:    ptr(45.8),
+					MemoryUtilization: ptr(80.0),
 				},
 			},
 			expectError: false,
@@ -487,8 +489,8 @@ func TestPrometheusClient_GetGPUMetrics(t *testing.T) {
 					responseKey = "gpu_metrics_total_memory"
 				case "gpu_metrics_utilization_percent":
 					responseKey = "gpu_metrics_utilization_percent"
-				case "gpu_metrics_temperature_celsius":
-					responseKey = "gpu_metrics_temperature_celsius"
+				case "gpu_metrics_temperature":
+					responseKey = "gpu_metrics_temperature"
 				case "gpu_metrics_cpu_utilization":
 					responseKey = "gpu_metrics_cpu_utilization"
 				case "gpu_metrics_memory_utilization":
@@ -567,13 +569,13 @@ func TestPrometheusClient_GetGPUMetrics(t *testing.T) {
 					if actualMetric.NodeName != expectedMetric.NodeName ||
 						actualMetric.GPUIndex != expectedMetric.GPUIndex ||
 						actualMetric.GPUName != expectedMetric.GPUName ||
-						actualMetric.GPUMemoryFree != expectedMetric.GPUMemoryFree ||
-						actualMetric.GPUMemoryUsed != expectedMetric.GPUMemoryUsed ||
-						actualMetric.GPUMemoryTotal != expectedMetric.GPUMemoryTotal ||
-						actualMetric.GPUUtilization != expectedMetric.GPUUtilization ||
-						actualMetric.GPUTemperature != expectedMetric.GPUTemperature ||
-						actualMetric.CPUUtilization != expectedMetric.CPUUtilization ||
-						actualMetric.MemoryUtilization != expectedMetric.MemoryUtilization {
+						!equalPtr(actualMetric.GPUMemoryFree, expectedMetric.GPUMemoryFree) ||
+						!equalPtr(actualMetric.GPUMemoryUsed, expectedMetric.GPUMemoryUsed) ||
+						!equalPtr(actualMetric.GPUMemoryTotal, expectedMetric.GPUMemoryTotal) ||
+						!equalPtr(actualMetric.GPUUtilization, expectedMetric.GPUUtilization) ||
+						!equalPtr(actualMetric.GPUTemperature, expectedMetric.GPUTemperature) ||
+						!equalPtr(actualMetric.CPUUtilization, expectedMetric.CPUUtilization) ||
+						!equalPtr(actualMetric.MemoryUtilization, expectedMetric.MemoryUtilization) {
 
 						t.Errorf("metrics mismatch for %s:\nexpected: %+v\nactual:   %+v",
 							key, expectedMetric, actualMetric)
@@ -728,3 +730,47 @@ func TestPrometheusClient_GetGPUMetrics_Integration(t *testing.T) {
 		t.Error("expected error for invalid URL")
 	}
 }
+
+// TestPrometheusClient_GetGPUMetrics_InvalidMemory tests that out-of-range memory values are left unset
+// with a warning and the other fields of the GPU are still returned
+func TestPrometheusClient_GetGPUMetrics_InvalidMemory(t *testing.T) {
+	for _, value := range []string{"-1", "1e300"} {
+		t.Run(value, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[
+					{"metric":{"__name__":"gpu_metrics_used_memory","hostname":"node1","gpu_id":"0"},"value":[1640995200,%q]},
+					{"metric":{"__name__":"gpu_metrics_total_memory","hostname":"node1","gpu_id":"0"},"value":[1640995200,"4096"]}]}}`, value)
+			}))
+			defer server.Close()
+
+			client := prometheus.NewClient(server.URL)
+			ctx, rep := report.NewContext(context.Background())
+			metrics, err := client.GetGPUMetrics(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(metrics) != 1 || metrics[0].GPUMemoryUsed != nil || !equalPtr(metrics[0].GPUMemoryTotal, ptr(4096)) {
+				t.Errorf("expected only the total memory to be set, got %+v", metrics)
+			}
+
+			warnings := rep.Warnings()
+			if len(warnings) != 1 || warnings[0].Query != prometheus.FieldGPUMemoryUsed || !strings.Contains(warnings[0].Message, "out of range") {
+				t.Errorf("expected an out of range warning for the used memory, got %+v", warnings)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
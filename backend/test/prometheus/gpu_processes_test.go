@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"k8s-gpu-monitoring/internal/models"
 	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/report"
 )
 
 // TestPrometheusClient_GetGPUProcesses tests the actual parsing of Prometheus responses into GPUProcess slice
@@ -580,3 +582,34 @@ func TestPrometheusClient_GetGPUProcesses_Integration(t *testing.T) {
 		t.Error("expected error for invalid URL")
 	}
 }
+
+// TestPrometheusClient_GetGPUProcesses_InvalidMemory tests that unparsable and out-of-range memory values
+// are reported as warnings and the other processes are still returned
+func TestPrometheusClient_GetGPUProcesses_InvalidMemory(t *testing.T) {
+	for _, value := range []string{"invalid_number", "-1", "1e300"} {
+		t.Run(value, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[
+					{"metric":{"hostname":"node1","gpu_id":"0","pid":"1"},"value":[1640995200,"2048"]},
+					{"metric":{"hostname":"node1","gpu_id":"0","pid":"2"},"value":[1640995200,%q]}]}}`, value)
+			}))
+			defer server.Close()
+
+			client := prometheus.NewClient(server.URL)
+			ctx, rep := report.NewContext(context.Background())
+			processes, err := client.GetGPUProcesses(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(processes) != 1 || processes[0].PID != 1 || processes[0].GPUMemory != 2048 {
+				t.Errorf("expected only the valid process, got %+v", processes)
+			}
+
+			warnings := rep.Warnings()
+			if len(warnings) != 1 || warnings[0].Query != prometheus.FieldProcessGPUMemory || !strings.Contains(warnings[0].Message, "pid 2") {
+				t.Errorf("expected a warning for pid 2, got %+v", warnings)
+			}
+		})
+	}
+}
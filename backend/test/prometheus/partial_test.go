@@ -0,0 +1,122 @@
+package prometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/report"
+)
+
+// customLabels are the labels published by the project's own exporter
+const customLabels = `{"hostname":"node1","gpu_id":"0","gpu_name":"NVIDIA Tesla V100"}`
+
+// newPartialServer serves value for every custom schema query except those in failing, which return 503
+func newPartialServer(t *testing.T, failing ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		for _, name := range failing {
+			if query == prometheus.SchemaCustom.Metrics[name].Query {
+				http.Error(w, "target down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":` + customLabels + `,"value":[1640995200,"42"]}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestPrometheusClient_GetGPUMetrics_Partial tests that failed queries leave their fields nil and are reported as warnings
+func TestPrometheusClient_GetGPUMetrics_Partial(t *testing.T) {
+	server := newPartialServer(t, prometheus.FieldCPUUtilization, prometheus.FieldGPUTemperature)
+	client := prometheus.NewClient(server.URL)
+
+	ctx, rep := report.NewContext(context.Background())
+	metrics, err := client.GetGPUMetrics(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+
+	m := metrics[0]
+	if m.GPUTemperature != nil || m.CPUUtilization != nil {
+		t.Errorf("expected failed fields to be nil, got temperature %v cpu %v", m.GPUTemperature, m.CPUUtilization)
+	}
+	if !equalPtr(m.GPUUtilization, ptr(42.0)) || !equalPtr(m.MemoryUtilization, ptr(42.0)) {
+		t.Errorf("expected succeeded fields to be set: %+v", m)
+	}
+
+	warnings := rep.Warnings()
+	if len(warnings) != 2 || warnings[0].Query != prometheus.FieldGPUTemperature || warnings[1].Query != prometheus.FieldCPUUtilization {
+		t.Fatalf("unexpected warnings %+v", warnings)
+	}
+	if warnings[0].Message == "" {
+		t.Error("expected warning message")
+	}
+}
+
+// TestPrometheusClient_GetGPUMetrics_AllGPUQueriesFail tests that node-level values alone are not returned
+func TestPrometheusClient_GetGPUMetrics_AllGPUQueriesFail(t *testing.T) {
+	server := newPartialServer(t,
+		prometheus.FieldGPUMemoryFree,
+		prometheus.FieldGPUMemoryUsed,
+		prometheus.FieldGPUMemoryTotal,
+		prometheus.FieldGPUUtilization,
+		prometheus.FieldGPUTemperature,
+	)
+	client := prometheus.NewClient(server.URL)
+
+	if _, err := client.GetGPUMetrics(context.Background()); err == nil {
+		t.Error("expected error when every per-GPU query fails")
+	}
+}
+
+// TestPrometheusClient_GetGPUMetrics_Values tests that fractional values are kept, NaN is absent and unparsable values are reported
+func TestPrometheusClient_GetGPUMetrics_Values(t *testing.T) {
+	values := map[string]string{
+		prometheus.SchemaCustom.Metrics[prometheus.FieldGPUUtilization].Query: "87.25",
+		prometheus.SchemaCustom.Metrics[prometheus.FieldGPUTemperature].Query: "NaN",
+		prometheus.SchemaCustom.Metrics[prometheus.FieldCPUUtilization].Query: "abc",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := values[r.URL.Query().Get("query")]
+		if !ok {
+			value = "1024"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":` + customLabels + `,"value":[1640995200,"` + value + `"]}]}}`))
+	}))
+	defer server.Close()
+
+	ctx, rep := report.NewContext(context.Background())
+	metrics, err := prometheus.NewClient(server.URL).GetGPUMetrics(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+
+	m := metrics[0]
+	if !equalPtr(m.GPUUtilization, ptr(87.25)) {
+		t.Errorf("expected utilization 87.25, got %v", m.GPUUtilization)
+	}
+	if m.GPUTemperature != nil || m.CPUUtilization != nil {
+		t.Errorf("expected NaN and unparsable values to be nil, got temperature %v cpu %v", m.GPUTemperature, m.CPUUtilization)
+	}
+	if !equalPtr(m.GPUMemoryUsed, ptr(1024)) {
+		t.Errorf("expected memory used 1024, got %v", m.GPUMemoryUsed)
+	}
+
+	warnings := rep.Warnings()
+	if len(warnings) != 1 || warnings[0].Query != prometheus.FieldCPUUtilization {
+		t.Errorf("expected a warning for the unparsable value only, got %+v", warnings)
+	}
+}
@@ -0,0 +1,144 @@
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+// fastRetry retries quickly enough for tests
+var fastRetry = prometheus.RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// newFlakyServer answers the first failures requests with status and later ones successfully, counting requests
+func newFlakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			http.Error(w, "unavailable", status)
+			return
+		}
+		w.Write([]byte(upResponse))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestClient_Retry tests that transient failures are retried and rejected queries are not
+func TestClient_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32
+		status       int
+		wantErr      bool
+		wantRequests int32
+	}{
+		{name: "recovers from 503", failures: 2, status: http.StatusServiceUnavailable, wantRequests: 3},
+		{name: "recovers from 429", failures: 1, status: http.StatusTooManyRequests, wantRequests: 2},
+		{name: "gives up after max attempts", failures: 5, status: http.StatusBadGateway, wantErr: true, wantRequests: 3},
+		{name: "does not retry bad queries", failures: 5, status: http.StatusBadRequest, wantErr: true, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newFlakyServer(t, tt.failures, tt.status)
+			client := prometheus.NewClient(server.URL, prometheus.WithRetry(fastRetry))
+
+			err := client.Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("expected %d requests, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+// TestClient_RetryDeadline tests that no retry is scheduled past the context deadline
+func TestClient_RetryDeadline(t *testing.T) {
+	server, requests := newFlakyServer(t, 5, http.StatusServiceUnavailable)
+	client := prometheus.NewClient(server.URL, prometheus.WithRetry(prometheus.RetryOptions{
+		MaxAttempts: 3,
+		BaseDelay:   time.Hour,
+		MaxDelay:    time.Hour,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.Ping(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to give up before the deadline, took %v", elapsed)
+	}
+	if got := requests.Load(); got > 2 {
+		t.Errorf("expected at most 2 requests, got %d", got)
+	}
+}
+
+// TestClient_CircuitBreaker tests that the circuit opens after repeated failures and closes after a successful trial
+func TestClient_CircuitBreaker(t *testing.T) {
+	server, requests := newFlakyServer(t, 2, http.StatusServiceUnavailable)
+	client := prometheus.NewClient(server.URL, prometheus.WithCircuitBreaker(prometheus.BreakerOptions{
+		Threshold: 2,
+		Cooldown:  50 * time.Millisecond,
+	}))
+
+	for range 2 {
+		if err := client.Ping(context.Background()); err == nil {
+			t.Fatal("expected error from the failing server")
+		}
+	}
+
+	// Open: fails without contacting Prometheus
+	err := client.Ping(context.Background())
+	if !errors.Is(err, prometheus.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected no request while open, got %d", got)
+	}
+	circuits := client.Circuits()
+	if len(circuits) != 1 || circuits[0].State != models.CircuitOpen || circuits[0].ConsecutiveFailures != 2 || circuits[0].RetryAt == "" {
+		t.Errorf("unexpected circuit status %+v", circuits)
+	}
+
+	// After the cooldown a trial request is let through and closes the circuit
+	time.Sleep(60 * time.Millisecond)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("unexpected error after cooldown: %v", err)
+	}
+	circuits = client.Circuits()
+	if len(circuits) != 1 || circuits[0].State != models.CircuitClosed || circuits[0].ConsecutiveFailures != 0 {
+		t.Errorf("expected a closed circuit, got %+v", circuits)
+	}
+}
+
+// TestClient_CircuitBreakerIgnoresRejectedQueries tests that responses other than 429 and 5xx keep the circuit closed
+func TestClient_CircuitBreakerIgnoresRejectedQueries(t *testing.T) {
+	server, _ := newFlakyServer(t, 5, http.StatusBadRequest)
+	client := prometheus.NewClient(server.URL, prometheus.WithCircuitBreaker(prometheus.BreakerOptions{
+		Threshold: 1,
+		Cooldown:  time.Minute,
+	}))
+
+	for range 3 {
+		if err := client.Ping(context.Background()); errors.Is(err, prometheus.ErrCircuitOpen) {
+			t.Fatal("expected the circuit to stay closed")
+		}
+	}
+
+	if circuits := prometheus.NewClient(server.URL).Circuits(); circuits != nil {
+		t.Errorf("expected no circuits without a breaker, got %+v", circuits)
+	}
+}
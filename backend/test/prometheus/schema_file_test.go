@@ -0,0 +1,189 @@
+package prometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+func writeSchemaFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write schema file: %v", err)
+	}
+	return path
+}
+
+func TestBuiltinSchemasAreValid(t *testing.T) {
+	for _, schema := range []prometheus.Schema{prometheus.SchemaCustom, prometheus.SchemaDCGM} {
+		if err := schema.Validate(); err != nil {
+			t.Errorf("built-in schema %s is invalid: %v", schema.Name, err)
+		}
+	}
+}
+
+// TestLoadSchema_YAML tests a YAML mapping with per-field label overrides
+func TestLoadSchema_YAML(t *testing.T) {
+	path := writeSchemaFile(t, "schema.yaml", `
+name: site
+labels:
+  node: node
+  gpu: index
+  gpu_name: model
+  pid: pid
+metrics:
+  gpu_utilization:
+    query: my_gpu_util
+  cpu_utilization:
+    query: 100 - avg by (node) (rate(node_cpu_seconds_total{mode="idle"}[5m])) * 100
+    labels:
+      node: node
+processes:
+  gpu_memory:
+    query: my_gpu_process_memory_bytes
+`)
+
+	schema, err := prometheus.LoadSchema(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if schema.Name != "site" || schema.Labels.GPU != "index" {
+		t.Errorf("unexpected schema: %+v", schema)
+	}
+	if len(schema.Metrics) != 2 || schema.Metrics["gpu_utilization"].Query != "my_gpu_util" {
+		t.Errorf("unexpected metrics mapping: %+v", schema.Metrics)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("query") {
+		case "my_gpu_util":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"node":"node1","index":"2","model":"NVIDIA L4"},"value":[1640995200,"42"]}]}}`))
+		default:
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"node":"node1"},"value":[1640995200,"12.5"]}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, prometheus.WithSchema(schema))
+	metrics, err := client.GetGPUMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+	m := metrics[0]
+	if m.NodeName != "node1" || m.GPUIndex != 2 || m.GPUName != "NVIDIA L4" || !equalPtr(m.GPUUtilization, ptr(42.0)) || !equalPtr(m.CPUUtilization, ptr(12.5)) {
+		t.Errorf("unexpected metric: %+v", m)
+	}
+}
+
+// TestLoadSchema_JSONBase tests a JSON mapping that overrides a built-in schema
+func TestLoadSchema_JSONBase(t *testing.T) {
+	path := writeSchemaFile(t, "schema.json", `{
+		"base": "dcgm",
+		"labels": {"node": "kubernetes_node"},
+		"metrics": {"temperature": {"query": "DCGM_FI_DEV_MEMORY_TEMP"}}
+	}`)
+
+	schema, err := prometheus.LoadSchema(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if schema.Name != "dcgm" {
+		t.Errorf("expected name inherited from base, got %q", schema.Name)
+	}
+	if schema.Labels.Node != "kubernetes_node" || schema.Labels.GPU != "gpu" {
+		t.Errorf("expected labels merged over base, got %+v", schema.Labels)
+	}
+	if schema.Metrics["temperature"].Query != "DCGM_FI_DEV_MEMORY_TEMP" {
+		t.Errorf("expected overridden temperature query, got %q", schema.Metrics["temperature"].Query)
+	}
+	if schema.Metrics["gpu_utilization"].Query != "DCGM_FI_DEV_GPU_UTIL" {
+		t.Errorf("expected inherited utilization query, got %q", schema.Metrics["gpu_utilization"].Query)
+	}
+}
+
+func TestLoadSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		contains []string
+	}{
+		{
+			name: "validation errors are all reported",
+			file: "schema.yaml",
+			content: `
+labels:
+  node: hostname
+metrics:
+  gpu_utilisation:
+    query: gpu_util
+  temperature:
+    query: ""
+  gpu_memory_used:
+    query: sum(gpu_used{hostname="a"}
+processes:
+  gpu_memory:
+    query: gpu_process_memory
+`,
+			contains: []string{
+				"metrics.gpu_utilisation: unknown field",
+				"metrics.temperature: query is empty",
+				"metrics.gpu_memory_used: unclosed",
+				"processes.gpu_memory: node, gpu and pid labels must be set",
+			},
+		},
+		{
+			name:     "missing gpu label",
+			file:     "schema.yaml",
+			content:  "labels:\n  node: hostname\nmetrics:\n  gpu_utilization:\n    query: gpu_util\n",
+			contains: []string{"metrics.gpu_utilization: gpu label is not set"},
+		},
+		{
+			name:     "unknown yaml key",
+			file:     "schema.yaml",
+			content:  "labels:\n  hostname: hostname\n",
+			contains: []string{"field hostname not found"},
+		},
+		{
+			name:     "unknown json key",
+			file:     "schema.json",
+			content:  `{"metric": {}}`,
+			contains: []string{"unknown field"},
+		},
+		{
+			name:     "unknown base",
+			file:     "schema.yaml",
+			content:  "base: nvidia-smi\n",
+			contains: []string{"unknown metric schema"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := prometheus.LoadSchema(writeSchemaFile(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
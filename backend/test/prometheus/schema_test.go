@@ -0,0 +1,145 @@
+package prometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+// dcgmLabels are the labels published by the stock dcgm-exporter
+const dcgmLabels = `{"Hostname":"node1","gpu":"0","modelName":"NVIDIA A100-SXM4-40GB","UUID":"GPU-1234"}`
+
+// TestPrometheusClient_GetGPUMetrics_DCGM tests reading metrics with the dcgm-exporter schema
+func TestPrometheusClient_GetGPUMetrics_DCGM(t *testing.T) {
+	responses := map[string]string{
+		prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUMemoryFree].Query:  "1073741824",
+		prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUMemoryUsed].Query:  "3221225472",
+		prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUMemoryTotal].Query: "4294967296",
+		prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUUtilization].Query: "87",
+		prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUTemperature].Query: "61",
+	}
+
+	var (
+		mu      sync.Mutex
+		queried = make(map[string]bool)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		value, ok := responses[query]
+		if !ok {
+			http.Error(w, "Unknown query", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		queried[query] = true
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":` + dcgmLabels + `,"value":[1640995200,"` + value + `"]}]}}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, prometheus.WithSchema(prometheus.SchemaDCGM))
+	metrics, err := client.GetGPUMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(queried) != len(responses) {
+		t.Errorf("expected %d queries, got %d", len(responses), len(queried))
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+
+	m := metrics[0]
+	if m.NodeName != "node1" || m.GPUIndex != 0 || m.GPUName != "NVIDIA A100-SXM4-40GB" || m.UUID != "GPU-1234" {
+		t.Errorf("unexpected identity: %+v", m)
+	}
+	if !equalPtr(m.GPUMemoryFree, ptr(1073741824)) || !equalPtr(m.GPUMemoryUsed, ptr(3221225472)) || !equalPtr(m.GPUMemoryTotal, ptr(4294967296)) {
+		t.Errorf("unexpected memory values: %+v", m)
+	}
+	if !equalPtr(m.GPUUtilization, ptr(87.0)) || !equalPtr(m.GPUTemperature, ptr(61.0)) {
+		t.Errorf("unexpected utilization or temperature: %+v", m)
+	}
+}
+
+// TestPrometheusClient_GetGPUProcesses_DCGM tests that the DCGM schema issues no process queries
+func TestPrometheusClient_GetGPUProcesses_DCGM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected query: %s", r.URL.Query().Get("query"))
+		http.Error(w, "Unknown query", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, prometheus.WithSchema(prometheus.SchemaDCGM))
+	processes, err := client.GetGPUProcesses(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(processes) != 0 {
+		t.Errorf("expected no processes, got %d", len(processes))
+	}
+}
+
+func TestLookupSchema(t *testing.T) {
+	for _, name := range []string{"custom", "dcgm"} {
+		schema, err := prometheus.LookupSchema(name)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", name, err)
+		}
+		if schema.Name != name {
+			t.Errorf("expected schema %s, got %s", name, schema.Name)
+		}
+	}
+
+	if _, err := prometheus.LookupSchema("nvidia-smi"); err == nil {
+		t.Error("expected error for unknown schema")
+	}
+}
+
+// TestPrometheusClient_GetGPUMetricsHistory_DCGM tests filtering of expressions that cannot carry label matchers
+func TestPrometheusClient_GetGPUMetricsHistory_DCGM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		if query == prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUMemoryUsed].Query {
+			// Arithmetic expressions come back unfiltered
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"Hostname":"node1","gpu":"0"},"values":[[1640995200,"1048576"]]},
+				{"metric":{"Hostname":"node1","gpu":"1"},"values":[[1640995200,"2097152"]]}]}}`))
+			return
+		}
+		if query != prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUUtilization].Query+`{Hostname="node1",gpu="1"}` &&
+			query != prometheus.SchemaDCGM.Metrics[prometheus.FieldGPUTemperature].Query+`{Hostname="node1",gpu="1"}` {
+			t.Errorf("expected matchers on bare metric query, got %s", query)
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"Hostname":"node1","gpu":"1"},"values":[[1640995200,"50"]]}]}}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, prometheus.WithSchema(prometheus.SchemaDCGM))
+	history, err := client.GetGPUMetricsHistory(context.Background(), models.MetricsQuery{
+		StartTime: "1640995200",
+		EndTime:   "1640995260",
+		Step:      "60",
+		Node:      "node1",
+		GPU:       "1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(history) != 1 || history[0].GPUIndex != 1 {
+		t.Fatalf("expected only GPU 1, got %+v", history)
+	}
+	if !equalPtr(history[0].Samples[0].GPUMemoryUsed, ptr(2097152)) || !equalPtr(history[0].Samples[0].GPUUtilization, ptr(50.0)) {
+		t.Errorf("unexpected sample: %+v", history[0].Samples[0])
+	}
+}
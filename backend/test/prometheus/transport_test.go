@@ -0,0 +1,279 @@
+package prometheus_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+)
+
+// upResponse is a minimal successful instant query response
+const upResponse = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+
+// newTransportClient returns a client for url sending requests through a transport built from cfg
+func newTransportClient(t *testing.T, url string, cfg prometheus.TransportConfig) *prometheus.Client {
+	t.Helper()
+	rt, err := prometheus.NewTransport(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return prometheus.NewClient(url, prometheus.WithTransport(rt))
+}
+
+// writeFile writes content to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestTransport_Headers tests that the tenant, extra headers and a reloaded bearer token are sent
+func TestTransport_Headers(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(upResponse))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenFile := writeFile(t, dir, "token", "first\n")
+
+	client := newTransportClient(t, server.URL, prometheus.TransportConfig{
+		BearerTokenFile: tokenFile,
+		TenantID:        "team-a",
+		Headers:         map[string]string{"X-Env": "prod"},
+	})
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("Authorization") != "Bearer first" || got.Get(prometheus.TenantHeader) != "team-a" || got.Get("X-Env") != "prod" {
+		t.Errorf("unexpected headers %v", got)
+	}
+
+	// A rotated token is picked up without restarting
+	writeFile(t, dir, "token", "second-token\n")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(tokenFile, later, later)
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("Authorization") != "Bearer second-token" {
+		t.Errorf("expected the rotated token, got %q", got.Get("Authorization"))
+	}
+}
+
+// TestTransport_BasicAuth tests that basic auth credentials are sent
+func TestTransport_BasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "grafana" || password != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(upResponse))
+	}))
+	defer server.Close()
+
+	client := newTransportClient(t, server.URL, prometheus.TransportConfig{
+		BasicAuthUsername:     "grafana",
+		BasicAuthPasswordFile: writeFile(t, t.TempDir(), "password", "s3cret"),
+	})
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestTransport_MissingCredentials tests that an unreadable credential file fails the request without
+// contacting Prometheus, retrying or counting towards the circuit breaker
+func TestTransport_MissingCredentials(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(upResponse))
+	}))
+	defer server.Close()
+
+	rt, err := prometheus.NewTransport(prometheus.TransportConfig{
+		BearerTokenFile: filepath.Join(t.TempDir(), "missing"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := prometheus.NewClient(server.URL,
+		prometheus.WithTransport(rt),
+		prometheus.WithRetry(fastRetry),
+		prometheus.WithCircuitBreaker(prometheus.BreakerOptions{Threshold: 1, Cooldown: time.Minute}),
+	)
+
+	for range 3 {
+		err := client.Ping(context.Background())
+		if err == nil || errors.Is(err, prometheus.ErrCircuitOpen) || !strings.HasPrefix(err.Error(), "reading credentials") {
+			t.Fatalf("expected a credentials error, got %v", err)
+		}
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("expected no request to reach Prometheus, got %d", got)
+	}
+	if circuits := client.Circuits(); len(circuits) != 1 || circuits[0].State != models.CircuitClosed || circuits[0].ConsecutiveFailures != 0 {
+		t.Errorf("expected a closed circuit without failures, got %+v", circuits)
+	}
+}
+
+// TestTransport_TLS tests server verification against a CA bundle and with verification disabled
+func TestTransport_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(upResponse))
+	}))
+	defer server.Close()
+
+	if err := newTransportClient(t, server.URL, prometheus.TransportConfig{}).Ping(context.Background()); err == nil {
+		t.Error("expected an untrusted certificate to be rejected")
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	caFile := writeFile(t, t.TempDir(), "ca.pem", string(caPEM))
+	if err := newTransportClient(t, server.URL, prometheus.TransportConfig{CAFile: caFile}).Ping(context.Background()); err != nil {
+		t.Errorf("unexpected error with CA bundle: %v", err)
+	}
+
+	if err := newTransportClient(t, server.URL, prometheus.TransportConfig{InsecureSkipVerify: true}).Ping(context.Background()); err != nil {
+		t.Errorf("unexpected error with verification disabled: %v", err)
+	}
+}
+
+// TestTransport_ClientCertificate tests that a client certificate is presented for mTLS
+func TestTransport_ClientCertificate(t *testing.T) {
+	certPEM, keyPEM, cert := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(upResponse))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	if err := newTransportClient(t, server.URL, prometheus.TransportConfig{InsecureSkipVerify: true}).Ping(context.Background()); err == nil {
+		t.Error("expected the server to reject a client without certificate")
+	}
+
+	dir := t.TempDir()
+	client := newTransportClient(t, server.URL, prometheus.TransportConfig{
+		InsecureSkipVerify: true,
+		CertFile:           writeFile(t, dir, "tls.crt", string(certPEM)),
+		KeyFile:            writeFile(t, dir, "tls.key", string(keyPEM)),
+	})
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// newClientCertificate returns a self-signed client certificate and key in PEM
+func newClientCertificate(t *testing.T) ([]byte, []byte, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gpu-monitoring"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		cert
+}
+
+// TestTransportConfig_Validate tests that every problem is reported together
+func TestTransportConfig_Validate(t *testing.T) {
+	err := prometheus.TransportConfig{
+		BasicAuthUsername: "grafana",
+		BearerTokenFile:   "/token",
+		CertFile:          "/tls.crt",
+		ProxyURL:          "proxy:3128",
+		Headers:           map[string]string{"Bad Header": "x"},
+	}.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"mutually exclusive", "basic_auth_password_file", "key_file", "invalid proxy_url", "invalid header name"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+
+	if _, err := prometheus.NewTransport(prometheus.TransportConfig{CAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Error("expected error for a missing CA file")
+	}
+}
+
+// TestTransportConfig_WithDefaults tests that cluster settings override the shared ones
+func TestTransportConfig_WithDefaults(t *testing.T) {
+	defaults := prometheus.TransportConfig{
+		BasicAuthUsername:     "grafana",
+		BasicAuthPasswordFile: "/password",
+		CAFile:                "/ca.pem",
+		TenantID:              "shared",
+		Headers:               map[string]string{"X-Env": "prod", "X-Team": "ml"},
+	}
+
+	cfg := prometheus.TransportConfig{
+		BearerTokenFile: "/token",
+		TenantID:        "team-a",
+		Headers:         map[string]string{"X-Team": "infra"},
+	}.WithDefaults(defaults)
+
+	if cfg.BearerTokenFile != "/token" || cfg.BasicAuthUsername != "" {
+		t.Errorf("expected the cluster credentials alone, got %+v", cfg)
+	}
+	if cfg.CAFile != "/ca.pem" || cfg.TenantID != "team-a" {
+		t.Errorf("unexpected merged settings %+v", cfg)
+	}
+	if cfg.Headers["X-Env"] != "prod" || cfg.Headers["X-Team"] != "infra" {
+		t.Errorf("unexpected headers %v", cfg.Headers)
+	}
+	if defaults.Headers["X-Team"] != "ml" {
+		t.Error("expected the defaults to be left unchanged")
+	}
+}
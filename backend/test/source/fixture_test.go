@@ -0,0 +1,101 @@
+package source_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/cache"
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/source"
+)
+
+func TestLoadFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	content := `{
+		"metrics": [
+			{"node_name": "node1", "gpu_index": 0, "gpu_name": "NVIDIA A100", "gpu_utilization": 75, "temperature": 65},
+			{"node_name": "node2", "gpu_index": 1, "gpu_name": "NVIDIA A100", "gpu_utilization": 10}
+		],
+		"processes": [
+			{"node_name": "node1", "gpu_index": 0, "pid": 1234, "user": "alice", "gpu_memory": 1024}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	fixture, err := source.LoadFixture(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	metrics, err := fixture.GetGPUMetrics(ctx)
+	if err != nil || len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d (%v)", len(metrics), err)
+	}
+	if !equalPtr(metrics[0].GPUUtilization, ptr(75.0)) || !equalPtr(metrics[0].GPUTemperature, ptr(65.0)) {
+		t.Errorf("unexpected metrics: %+v", metrics[0])
+	}
+
+	processes, err := fixture.GetGPUProcesses(ctx)
+	if err != nil || len(processes) != 1 || processes[0].User != "alice" {
+		t.Errorf("unexpected processes: %+v (%v)", processes, err)
+	}
+
+	if err := fixture.Ping(ctx); err != nil {
+		t.Errorf("unexpected ping error: %v", err)
+	}
+
+	history, err := fixture.GetGPUMetricsHistory(ctx, models.MetricsQuery{Node: "node2"})
+	if err != nil || len(history) != 1 || history[0].GPUIndex != 1 {
+		t.Errorf("unexpected filtered history: %+v (%v)", history, err)
+	}
+}
+
+func TestLoadFixture_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, []byte(`{"metrics": "not a list"}`), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	if _, err := source.LoadFixture(path); err == nil {
+		t.Error("expected error for invalid fixture")
+	}
+	if _, err := source.LoadFixture(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing fixture")
+	}
+}
+
+func TestAs(t *testing.T) {
+	client := prometheus.NewClient("http://localhost:9090")
+	wrapped := cache.New(client, cache.Options{})
+
+	history, ok := source.As[source.HistorySource](wrapped)
+	if !ok {
+		t.Fatal("expected history source to be found through the cache")
+	}
+	if history != source.HistorySource(client) {
+		t.Error("expected the wrapped Prometheus client to be returned")
+	}
+
+	if _, ok := source.As[*source.Fixture](wrapped); ok {
+		t.Error("expected no fixture in the chain")
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
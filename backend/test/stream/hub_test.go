@@ -0,0 +1,136 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/stream"
+)
+
+func waitSnapshot(t *testing.T, sub *stream.Subscription) stream.Snapshot {
+	t.Helper()
+	select {
+	case snapshot, ok := <-sub.C():
+		if !ok {
+			t.Fatal("subscription closed unexpectedly")
+		}
+		return snapshot
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for snapshot")
+	}
+	return stream.Snapshot{}
+}
+
+// TestHub_FanOut tests that one poll is shared by every subscriber
+func TestHub_FanOut(t *testing.T) {
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) ([]models.GPUMetrics, error) {
+		calls.Add(1)
+		return []models.GPUMetrics{{NodeName: "node1", GPUIndex: 0}}, nil
+	}
+
+	hub := stream.NewHub(fetch, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	subs := make([]*stream.Subscription, 20)
+	for i := range subs {
+		subs[i] = hub.Subscribe()
+	}
+
+	for _, sub := range subs {
+		snapshot := waitSnapshot(t, sub)
+		if len(snapshot.Metrics) != 1 || snapshot.Metrics[0].NodeName != "node1" {
+			t.Errorf("unexpected snapshot: %+v", snapshot)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 fetch for 20 subscribers, got %d", got)
+	}
+	if hub.SubscriberCount() != 20 {
+		t.Errorf("expected 20 subscribers, got %d", hub.SubscriberCount())
+	}
+}
+
+// TestHub_NoPollingWithoutSubscribers tests that the hub stays idle with nobody listening
+func TestHub_NoPollingWithoutSubscribers(t *testing.T) {
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) ([]models.GPUMetrics, error) {
+		calls.Add(1)
+		return nil, nil
+	}
+
+	hub := stream.NewHub(fetch, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	if got := calls.Load(); got != 0 {
+		t.Errorf("expected no fetches without subscribers, got %d", got)
+	}
+}
+
+// TestHub_SlowSubscriber tests that a slow reader only keeps the latest snapshot
+func TestHub_SlowSubscriber(t *testing.T) {
+	fetch := func(ctx context.Context) ([]models.GPUMetrics, error) {
+		return nil, errors.New("mock prometheus error")
+	}
+
+	hub := stream.NewHub(fetch, 5*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow := hub.Subscribe()
+	go hub.Run(ctx)
+
+	// Let several polls pile up without reading
+	deadline := time.Now().Add(2 * time.Second)
+	for slow.Dropped() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if slow.Dropped() < 2 {
+		t.Fatalf("expected dropped snapshots for slow subscriber, got %d", slow.Dropped())
+	}
+
+	snapshot := waitSnapshot(t, slow)
+	if snapshot.Sequence < 3 {
+		t.Errorf("expected a recent snapshot, got sequence %d", snapshot.Sequence)
+	}
+	if snapshot.Err == nil {
+		t.Error("expected fetch error to be carried in snapshot")
+	}
+}
+
+// TestHub_CloseOnStop tests that subscriptions end when the hub stops
+func TestHub_CloseOnStop(t *testing.T) {
+	fetch := func(ctx context.Context) ([]models.GPUMetrics, error) {
+		return nil, nil
+	}
+
+	hub := stream.NewHub(fetch, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hub.Run(ctx)
+		close(done)
+	}()
+
+	sub := hub.Subscribe()
+	waitSnapshot(t, sub)
+	cancel()
+	<-done
+
+	if _, ok := <-sub.C(); ok {
+		t.Error("expected subscription channel to be closed")
+	}
+	if hub.Subscribe() != nil {
+		t.Error("expected subscribe to fail after hub stopped")
+	}
+}
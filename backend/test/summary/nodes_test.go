@@ -0,0 +1,83 @@
+package summary_test
+
+import (
+	"slices"
+	"testing"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/summary"
+)
+
+// TestByNode tests aggregating GPU rows per node without repeating node-level utilization
+func TestByNode(t *testing.T) {
+	metrics := []models.GPUMetrics{
+		{NodeName: "node2", GPUIndex: 0, GPUName: "NVIDIA A100", GPUMemoryUsed: ptr(1000), GPUMemoryTotal: ptr(4000), GPUMemoryFree: ptr(3000), GPUUtilization: ptr(10.0), GPUTemperature: ptr(40.0), CPUUtilization: ptr(20.0), MemoryUtilization: ptr(30.0)},
+		{NodeName: "node1", GPUIndex: 1, GPUName: "NVIDIA Tesla V100", GPUMemoryUsed: ptr(2000), GPUMemoryTotal: ptr(4000), GPUMemoryFree: ptr(2000), GPUUtilization: ptr(50.0), GPUTemperature: ptr(70.0), CPUUtilization: ptr(45.0), MemoryUtilization: ptr(60.0)},
+		{NodeName: "node1", GPUIndex: 0, GPUName: "NVIDIA Tesla T4", GPUMemoryUsed: ptr(1000), GPUMemoryTotal: ptr(4000), GPUMemoryFree: ptr(3000), GPUUtilization: ptr(75.0), GPUTemperature: ptr(65.0), CPUUtilization: ptr(45.0), MemoryUtilization: ptr(60.0)},
+		{NodeName: "node1", GPUIndex: 2, GPUName: "NVIDIA Tesla V100", GPUMemoryUsed: ptr(0), GPUMemoryTotal: ptr(4000), GPUMemoryFree: ptr(4000), GPUUtilization: ptr(0.0), GPUTemperature: ptr(30.0), CPUUtilization: ptr(45.0), MemoryUtilization: ptr(60.0)},
+	}
+
+	nodes := summary.ByNode(metrics)
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(nodes))
+	}
+
+	node := nodes[0]
+	if node.NodeName != "node1" {
+		t.Fatalf("expected nodes sorted by name, got %s first", node.NodeName)
+	}
+	if node.GPUCount != 3 {
+		t.Errorf("expected 3 GPUs, got %d", node.GPUCount)
+	}
+	if !slices.Equal(node.GPUModels, []string{"NVIDIA Tesla T4", "NVIDIA Tesla V100"}) {
+		t.Errorf("unexpected GPU models %v", node.GPUModels)
+	}
+	if !equalPtr(node.GPUMemoryUsed, ptr(3000)) || !equalPtr(node.GPUMemoryTotal, ptr(12000)) || !equalPtr(node.GPUMemoryFree, ptr(9000)) {
+		t.Errorf("unexpected memory totals %+v", node)
+	}
+	if node.GPUUtilization == nil || *node.GPUUtilization < 41.66 || *node.GPUUtilization > 41.67 {
+		t.Errorf("expected mean utilization ~41.67, got %v", node.GPUUtilization)
+	}
+	if !equalPtr(node.MaxTemperature, ptr(70.0)) {
+		t.Errorf("expected max temperature 70, got %v", node.MaxTemperature)
+	}
+	if !equalPtr(node.CPUUtilization, ptr(45.0)) || !equalPtr(node.MemoryUtilization, ptr(60.0)) {
+		t.Errorf("expected node utilization taken once, got cpu %v memory %v", node.CPUUtilization, node.MemoryUtilization)
+	}
+
+	if empty := summary.ByNode(nil); empty == nil || len(empty) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", empty)
+	}
+}
+
+// TestByNode_Clusters tests that nodes of the same name in different clusters are summarized apart
+func TestByNode_Clusters(t *testing.T) {
+	nodes := summary.ByNode([]models.GPUMetrics{
+		{Cluster: "tokyo", NodeName: "node1", GPUIndex: 0, GPUUtilization: ptr(20.0)},
+		{Cluster: "osaka", NodeName: "node1", GPUIndex: 0, GPUUtilization: ptr(80.0)},
+		{Cluster: "osaka", NodeName: "node1", GPUIndex: 1, GPUUtilization: ptr(60.0)},
+	})
+
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(nodes))
+	}
+	if nodes[0].Cluster != "osaka" || nodes[0].GPUCount != 2 || !equalPtr(nodes[0].GPUUtilization, ptr(70.0)) {
+		t.Errorf("unexpected osaka summary %+v", nodes[0])
+	}
+	if nodes[1].Cluster != "tokyo" || nodes[1].GPUCount != 1 || !equalPtr(nodes[1].GPUUtilization, ptr(20.0)) {
+		t.Errorf("unexpected tokyo summary %+v", nodes[1])
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,172 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"k8s-gpu-monitoring/internal/middleware"
+	"k8s-gpu-monitoring/internal/prometheus"
+	"k8s-gpu-monitoring/internal/tracing"
+)
+
+// recordSpans installs an in-memory tracer provider for the duration of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func attr(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// TestClientSpans tests that each metric query gets a child span and trace context reaches Prometheus
+func TestClientSpans(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var mu sync.Mutex
+	var traceparents []string
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"hostname":"node1","gpu_id":"0","gpu_name":"Tesla V100"},"value":[1640995200,"50"]},
+			{"metric":{"hostname":"node1","gpu_id":"1","gpu_name":"Tesla V100"},"value":[1640995200,"60"]}]}}`))
+	}))
+	defer promServer.Close()
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "root")
+	if _, err := prometheus.NewClient(promServer.URL).GetGPUMetrics(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root.End()
+	traceID := root.SpanContext().TraceID()
+
+	var querySpans int
+	for _, span := range recorder.Ended() {
+		if !strings.HasPrefix(span.Name(), "prometheus.query ") {
+			continue
+		}
+		querySpans++
+
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("span %s: expected the root span as parent", span.Name())
+		}
+		if name, ok := attr(span, "prometheus.query.name"); !ok || span.Name() != "prometheus.query "+name.AsString() {
+			t.Errorf("span %s: unexpected query name attribute %v", span.Name(), name)
+		}
+		if size, ok := attr(span, "prometheus.result.size"); !ok || size.AsInt64() != 2 {
+			t.Errorf("span %s: expected result size 2, got %v", span.Name(), size)
+		}
+		if _, ok := attr(span, "db.query.text"); !ok {
+			t.Errorf("span %s: expected the PromQL query", span.Name())
+		}
+	}
+
+	if querySpans == 0 || querySpans != len(traceparents) {
+		t.Fatalf("expected one span per query, got %d spans for %d queries", querySpans, len(traceparents))
+	}
+	for _, tp := range traceparents {
+		if !strings.Contains(tp, traceID.String()) {
+			t.Errorf("expected traceparent with trace %s, got %q", traceID, tp)
+		}
+	}
+}
+
+// TestClientSpans_Error tests that failed queries mark their span as an error
+func TestClientSpans_Error(t *testing.T) {
+	recorder := recordSpans(t)
+
+	promServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer promServer.Close()
+
+	if _, err := prometheus.NewClient(promServer.URL).Query(context.Background(), "up"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "prometheus.query" || spans[0].Status().Code != codes.Error {
+		t.Fatalf("expected one failed prometheus.query span, got %v", spans)
+	}
+}
+
+// TestTracingMiddleware tests server spans named after the route and continued from the caller's trace
+func TestTracingMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/gpu/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := middleware.Chain(mux, middleware.RequestID, middleware.Tracing(mux))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/api/v1/gpu/metrics?node=node1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/v1/gpu/metrics" {
+		t.Errorf("expected the route as span name, got %q", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != traceID {
+		t.Errorf("expected trace %s to be continued, got %s", traceID, span.SpanContext().TraceID())
+	}
+	if status, _ := attr(span, "http.response.status_code"); status.AsInt64() != http.StatusInternalServerError {
+		t.Errorf("expected status code 500, got %v", status)
+	}
+	if span.Status().Code != codes.Error {
+		t.Error("expected the span to be marked as an error")
+	}
+	if _, ok := attr(span, "http.request.header.x-request-id"); !ok {
+		t.Error("expected the request ID attribute")
+	}
+}
+
+// TestSetup tests that tracing is disabled without an endpoint and rejects invalid ones
+func TestSetup(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+
+	if _, err := tracing.Setup(context.Background(), tracing.Options{Endpoint: "otel-collector:4318"}); err == nil {
+		t.Error("expected an error for an endpoint without scheme")
+	}
+}
@@ -0,0 +1,72 @@
+package usage_test
+
+import (
+	"testing"
+
+	"k8s-gpu-monitoring/internal/models"
+	"k8s-gpu-monitoring/internal/usage"
+)
+
+var processes = []models.GPUProcess{
+	{NodeName: "node1", GPUIndex: 0, PID: 1, User: "alice", Namespace: "ml", GPUMemory: 4096},
+	{NodeName: "node1", GPUIndex: 0, PID: 2, User: "alice", Namespace: "ml", GPUMemory: 1024},
+	{NodeName: "node2", GPUIndex: 0, PID: 3, User: "alice", Namespace: "batch", GPUMemory: 2048},
+	{NodeName: "node1", GPUIndex: 1, PID: 4, User: "bob", Namespace: "ml", GPUMemory: 8192},
+	{NodeName: "node2", GPUIndex: 1, PID: 5, GPUMemory: 512},
+}
+
+// TestByUser tests summing memory and counting distinct GPUs per user
+func TestByUser(t *testing.T) {
+	got := usage.ByUser(processes)
+
+	expected := []models.GPUUsage{
+		{Name: "bob", GPUMemory: 8192, GPUCount: 1, ProcessCount: 1},
+		{Name: "alice", GPUMemory: 7168, GPUCount: 2, ProcessCount: 3},
+		{Name: usage.Unknown, GPUMemory: 512, GPUCount: 1, ProcessCount: 1, Unattributed: true},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d users, got %d: %+v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+// TestByNamespace tests grouping by namespace with unattributed processes under Unknown
+func TestByNamespace(t *testing.T) {
+	got := usage.ByNamespace(processes)
+
+	expected := []models.GPUUsage{
+		{Name: "ml", GPUMemory: 13312, GPUCount: 2, ProcessCount: 3},
+		{Name: "batch", GPUMemory: 2048, GPUCount: 1, ProcessCount: 1},
+		{Name: usage.Unknown, GPUMemory: 512, GPUCount: 1, ProcessCount: 1, Unattributed: true},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d namespaces, got %d: %+v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+
+	if empty := usage.ByNamespace(nil); empty == nil || len(empty) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", empty)
+	}
+}
+
+// TestByUser_UnknownName tests that a user actually named "unknown" is kept apart from unattributed processes
+func TestByUser_UnknownName(t *testing.T) {
+	got := usage.ByUser([]models.GPUProcess{
+		{NodeName: "node1", GPUIndex: 0, PID: 1, User: usage.Unknown, GPUMemory: 1024},
+		{NodeName: "node1", GPUIndex: 1, PID: 2, GPUMemory: 1024},
+	})
+
+	if len(got) != 2 || got[0].Unattributed || !got[1].Unattributed || got[0].Name != usage.Unknown || got[1].Name != usage.Unknown {
+		t.Errorf("expected the named user before the unattributed group, got %+v", got)
+	}
+}
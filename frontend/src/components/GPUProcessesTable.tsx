@@ -12,7 +12,7 @@ import { searchContext } from "../utils/contexts";
 // ...existing code...
 import type { ApiResponse, GPUProcess } from "../types/api";
 import { mockGPUProcesses } from "../types/api.mock";
-import { getConfig } from "../utils/config";
+import { getApiHeaders, getConfig } from "../utils/config";
 import { convertGPUProcesses } from "../utils/convert";
 import { getComparator } from "../utils/sort";
 import { isHighUsage } from "../utils/usage";
@@ -27,7 +27,9 @@ const fetchGpuProcessesWithFallback = async (): Promise<
     console.log(
       `Fetching GPU processes from: ${API_BASE_URL}/api/v1/gpu/processes`
     );
-    const res = await fetch(`${API_BASE_URL}/api/v1/gpu/processes`);
+    const res = await fetch(`${API_BASE_URL}/api/v1/gpu/processes`, {
+      headers: getApiHeaders(),
+    });
     if (!res.ok) throw new Error(`API error: ${res.status}`);
     const data = (await res.json()) as ApiResponse<GPUProcess[]>;
     return data;
@@ -39,7 +41,8 @@ const fetchGpuProcessesWithFallback = async (): Promise<
 };
 
 type Order = "asc" | "desc";
-type GpuProcessRowKey = keyof GPUProcess;
+// cluster・staleは列として表示しない
+type GpuProcessRowKey = Exclude<keyof GPUProcess, "cluster" | "stale">;
 
 const columns: {
   id: GpuProcessRowKey;
@@ -186,6 +189,8 @@ const GPUProcessesTable = () => {
                         backgroundColor: isHighUsage(col.id, row[col.id])
                           ? "#ef9a9a"
                           : "white",
+                        // 最終記録が古い行はグレーで表示
+                        color: row.stale ? "text.disabled" : undefined,
                       }}
                     >
                       {row[col.id]}
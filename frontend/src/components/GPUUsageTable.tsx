@@ -12,11 +12,11 @@ import { Box, CircularProgress, Typography } from "@mui/material";
 import { styled } from "@mui/material/styles";
 import type { ApiResponse, GPUMetrics } from "../types/api";
 import { mockGpuMetrics } from "../types/api.mock";
-import { getConfig } from "../utils/config";
+import { getApiHeaders, getConfig } from "../utils/config";
 import { searchContext } from "../utils/contexts";
 import { convertGPUMetrics } from "../utils/convert";
 import { getComparator } from "../utils/sort";
-import { isHighUsage } from "../utils/usage";
+import { formatValue, isHighUsage } from "../utils/usage";
 
 const config = getConfig();
 const API_BASE_URL = config.API_BASE_URL;
@@ -30,7 +30,9 @@ const fetchGpuMetricsWithFallback = async (): Promise<
     console.log(
       `Fetching GPU metrics from: ${API_BASE_URL}/api/v1/gpu/metrics`
     );
-    const res = await fetch(`${API_BASE_URL}/api/v1/gpu/metrics`);
+    const res = await fetch(`${API_BASE_URL}/api/v1/gpu/metrics`, {
+      headers: getApiHeaders(),
+    });
     if (!res.ok) throw new Error(`API error: ${res.status}`);
     const data = (await res.json()) as ApiResponse<GPUMetrics[]>;
     return data;
@@ -42,7 +44,8 @@ const fetchGpuMetricsWithFallback = async (): Promise<
 };
 
 type Order = "asc" | "desc";
-type GpuRowKey = keyof GPUMetrics;
+// cluster・staleは列として表示しない
+type GpuRowKey = Exclude<keyof GPUMetrics, "cluster" | "stale">;
 
 const columns: {
   id: GpuRowKey;
@@ -190,9 +193,11 @@ const GPUUsageTable = () => {
                         backgroundColor: isHighUsage(col.id, row[col.id])
                           ? "#ef9a9a"
                           : "white",
+                        // 最終記録が古い行はグレーで表示
+                        color: row.stale ? "text.disabled" : undefined,
                       }}
                     >
-                      {row[col.id]}
+                      {formatValue(row[col.id])}
                     </TableCell>
                   ))}
                 </TableRow>
@@ -1,27 +1,49 @@
 // API response types for backend endpoints
 
 export interface ApiResponse<T = unknown> {
+  // Response format version; 2 and later report unreadable values as null instead of 0
+  version?: number;
   success: boolean;
   data?: T;
   error?: string;
   message?: string;
+  warnings?: ApiWarning[];
+  // Outcome per cluster when the backend federates several Prometheus servers
+  clusters?: ClusterStatus[];
+}
+
+// A failed upstream query whose values are null in a partial response
+export interface ApiWarning {
+  cluster?: string;
+  query: string;
+  message: string;
+}
+
+export interface ClusterStatus {
+  name: string;
+  status: 'ok' | 'error';
+  error?: string;
 }
 
 export interface GPUMetrics {
+  cluster?: string;
   node_name: string;
   gpu_index: number;
   gpu_name: string;
-  gpu_utilization: number;
-  gpu_memory_used: number;
-  gpu_memory_total: number;
-  memory_free: number;
-  temperature: number;
-  cpu_utilization: number;
-  memory_utilization: number;
-  timestamp: string; // ISO8601
+  gpu_utilization: number | null;
+  gpu_memory_used: number | null;
+  gpu_memory_total: number | null;
+  memory_free: number | null;
+  temperature: number | null;
+  cpu_utilization: number | null;
+  memory_utilization: number | null;
+  timestamp: string; // RFC3339, when the GPU was last sampled
+  // Set when the last sample is older than the backend's staleness threshold, or unknown
+  stale?: boolean;
 }
 
 export interface GPUProcess {
+  cluster?: string;
   node_name: string;
   gpu_index: number;
   pid: number;
@@ -30,4 +52,5 @@ export interface GPUProcess {
   command: string;
   gpu_memory: number;
   timestamp: string;
+  stale?: boolean;
 }
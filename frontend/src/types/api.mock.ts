@@ -2,6 +2,7 @@
 import type { ApiResponse, GPUMetrics, GPUProcess } from "./api";
 
 export const mockGpuMetrics: ApiResponse<GPUMetrics[]> = {
+  version: 2,
   success: true,
   message: "GPU metrics retrieved successfully",
   data: [
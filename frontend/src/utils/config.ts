@@ -2,4 +2,10 @@ export const getConfig = () => {
   return {
     API_BASE_URL: "/api",
   };
-}; 
\ No newline at end of file
+}; 
+// タイムスタンプをブラウザのタイムゾーンで受け取るためのヘッダー
+export const getApiHeaders = () => {
+  return {
+    "Accept-Timezone": Intl.DateTimeFormat().resolvedOptions().timeZone,
+  };
+};
@@ -10,7 +10,8 @@ export const convertGPUMetrics = (ms: GPUMetrics[]) => {
   ] as const;
   for (const m of ms) {
     for (const key of convertKeys) {
-      m[key] = convertBytestoMiB(m[key]);
+      const value = m[key];
+      m[key] = value === null ? null : convertBytestoMiB(value);
     }
   }
 };
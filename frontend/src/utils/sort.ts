@@ -1,6 +1,10 @@
 export const descendingComparator = <T>(a: T, b: T, orderBy: keyof T) => {
-  const aValue = a[orderBy];
-  const bValue = b[orderBy];
+  // 値のない項目(null)は最小値として扱う
+  const aValue = a[orderBy] ?? -Infinity;
+  const bValue = b[orderBy] ?? -Infinity;
+  if (aValue === bValue) {
+    return 0;
+  }
   if (typeof aValue === "number" && typeof bValue === "number") {
     return bValue - aValue;
   }
@@ -7,8 +7,22 @@ const columnsWithPercent = [
 ];
 const columnsWithTmp = ["temperature"];
 
-export const isHighUsage = (id: string, value: string | number) => {
-  if (typeof value === "string") {
+// 値が取得できなかった項目(null)に表示する文字列
+export const NOT_AVAILABLE = "n/a";
+
+export const formatValue = (value: string | number | null) => {
+  if (value === null) {
+    return NOT_AVAILABLE;
+  }
+  if (typeof value === "number" && !Number.isInteger(value)) {
+    // 使用率・温度は小数第1位まで表示
+    return value.toFixed(1);
+  }
+  return value;
+};
+
+export const isHighUsage = (id: string, value: string | number | null) => {
+  if (typeof value === "string" || value === null) {
     return false;
   }
   if (columnsWithPercent.includes(id)) {